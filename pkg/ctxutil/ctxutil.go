@@ -19,6 +19,7 @@ const (
 	IsAdmin     = "is_admin"
 	Locale      = "locale"
 	Timezone    = "timezone"
+	APIVersion  = "api_version"
 )
 
 // Get 从 Context 安全获取指定 key 的值并断言为类型 T。
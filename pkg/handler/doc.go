@@ -1 +1,11 @@
+// Package handler 提供可直接挂载的通用 HTTP 处理函数。
+//
+//   - [Routes]: 以 JSON 输出服务注册的路由清单
+//   - [PrintRoutes]: 以表格输出路由清单，供 cmd 子命令与 CI 比对路由变更
+//
+// 使用示例：
+//
+//	inv := routes.NewInventory()
+//	routes.MustRegister(api, userRoutes, routes.WithInventory(inv))
+//	r.GET("/debug/routes", handler.Routes(inv))
 package handler
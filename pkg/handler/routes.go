@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/routes"
+)
+
+// Routes 返回输出路由清单的处理函数。
+//
+// 支持查询参数筛选：
+//   - tag: 标签，如 ?tag=users
+//   - operation: 操作模式，如 ?operation=sys:users:*
+//
+// 清单暴露了服务的全部接口，生产环境应挂载在受保护的路由组下。
+func Routes(inv *routes.Inventory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := inv.List(routes.RouteFilter{
+			Tag:       c.Query("tag"),
+			Operation: c.Query("operation"),
+		})
+		response.OK(c, list)
+	}
+}
+
+// PrintRoutes 以对齐的表格输出路由清单。
+//
+// 输出按路径、方法排序且不含运行时信息，适合提交到仓库或在 CI 中比对版本间的路由变更：
+//
+//	METHOD  PATH         VERSION  OPERATION         ACCESS   TAGS   MIDDLEWARES
+//	GET     /api/health  -        public:health:ok  public   -      -
+//	GET     /api/users   1        sys:users:list    private  users  middleware.RequirePermissionWithConfig
+func PrintRoutes(w io.Writer, list []routes.RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tVERSION\tOPERATION\tACCESS\tTAGS\tMIDDLEWARES")
+	for _, r := range list {
+		access := "private"
+		if r.Public {
+			access = "public"
+		}
+		version := "-"
+		if r.Version > 0 {
+			version = strconv.Itoa(r.Version)
+		}
+		if r.Deprecated {
+			version += " (deprecated)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Method, r.Path, version, orDash(r.Operation), access,
+			orDash(strings.Join(r.Tags, ",")), orDash(strings.Join(r.Middlewares, ",")))
+	}
+	return tw.Flush()
+}
+
+// orDash 空值显示为 "-"，保持列对齐。
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
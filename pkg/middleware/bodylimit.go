@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+const (
+	// DefaultBodyLimit 请求体的默认上限（字节）。
+	DefaultBodyLimit = 1 << 20
+	// DefaultMultipartLimit multipart/form-data 请求体的默认上限（字节）。
+	DefaultMultipartLimit = 32 << 20
+)
+
+// BodyLimitConfig 请求体大小限制中间件配置。
+type BodyLimitConfig struct {
+	// Limit 请求体上限（字节），默认 [DefaultBodyLimit]，<0 表示不限制。
+	Limit int64
+
+	// MultipartLimit multipart/form-data（文件上传）请求体上限（字节），
+	// 默认 [DefaultMultipartLimit]，<0 表示不限制。
+	MultipartLimit int64
+
+	// Skip 跳过限制的请求（可选）。
+	Skip func(c *gin.Context) bool
+}
+
+// bodyLimitKey 是请求体限制状态在 Gin context 中的键名。
+const bodyLimitKey = "body_limit"
+
+// BodyLimit 创建请求体大小限制中间件，multipart 请求使用 [DefaultMultipartLimit]。
+//
+// 详见 [BodyLimitWithConfig]。
+func BodyLimit(limit int64) gin.HandlerFunc {
+	return BodyLimitWithConfig(BodyLimitConfig{Limit: limit})
+}
+
+// BodyLimitWithConfig 创建请求体大小限制中间件。
+//
+// 处理流程：
+//   - 以 http.MaxBytesReader 的方式包装 c.Request.Body，读取超出上限时返回 *http.MaxBytesError
+//   - 不在本中间件中按 Content-Length 提前拒绝：路由级 [RouteBodyLimit] 在其后执行并可能放宽上限，
+//     因此统一在读取请求体时按最终生效的上限判断，Content-Length 已超出上限时首次读取即返回错误
+//   - Content-Length 未知时，读取超出上限即返回错误
+//   - 读取超限后处理函数返回的 400 响应（如 c.ShouldBindJSON 失败）替换为 413（response.PayloadTooLarge）；
+//     routes.Handle 直接返回 413
+//
+// 路由可通过 [RouteBodyLimit] 或 routes.Route.BodyLimit 覆盖上限（如文件上传接口）。
+//
+// 示例：
+//
+//	r.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{
+//	    Limit:          512 << 10,
+//	    MultipartLimit: 10 << 20,
+//	}))
+func BodyLimitWithConfig(cfg BodyLimitConfig) gin.HandlerFunc {
+	if cfg.Limit == 0 {
+		cfg.Limit = DefaultBodyLimit
+	}
+	if cfg.MultipartLimit == 0 {
+		cfg.MultipartLimit = DefaultMultipartLimit
+	}
+	return func(c *gin.Context) {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			c.Next()
+			return
+		}
+
+		limit := cfg.Limit
+		if mt, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); mt == "multipart/form-data" {
+			limit = cfg.MultipartLimit
+		}
+
+		w := c.Writer
+		limitBody(c, limit)
+		c.Next()
+		c.Writer = w
+	}
+}
+
+// RouteBodyLimit 为当前路由设置请求体上限（字节），<0 表示不限制。
+//
+// 位于 [BodyLimitWithConfig] 之后时覆盖其上限（可大于默认值），否则单独生效。
+// Content-Length 超出上限时直接返回 413，不执行后续处理链。
+// 声明式路由设置 routes.Route.BodyLimit 即可自动添加。
+func RouteBodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := c.Writer
+		if b, ok := ctxutil.Get[*limitedBody](c, bodyLimitKey); ok {
+			b.limit = limit
+		} else {
+			limitBody(c, limit)
+		}
+
+		if limit >= 0 && c.Request.ContentLength > limit {
+			response.PayloadTooLarge(c)
+			c.Abort()
+		} else {
+			c.Next()
+		}
+		c.Writer = w
+	}
+}
+
+// limitBody 包装请求体与响应写入器。
+func limitBody(c *gin.Context, limit int64) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+	b := &limitedBody{ReadCloser: c.Request.Body, limit: limit, length: c.Request.ContentLength}
+	c.Request.Body = b
+	c.Set(bodyLimitKey, b)
+	c.Writer = &bodyLimitWriter{ResponseWriter: c.Writer, body: b}
+}
+
+// limitedBody 可调整上限的 http.MaxBytesReader。
+type limitedBody struct {
+	io.ReadCloser
+	limit  int64 // <0 表示不限制
+	length int64 // Content-Length，未知时为 -1
+	read   int64
+	err    error // 超出上限后的错误
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.limit < 0 {
+		n, err := b.ReadCloser.Read(p)
+		b.read += int64(n)
+		return n, err
+	}
+	if b.length > b.limit || b.read > b.limit {
+		b.err = &http.MaxBytesError{Limit: b.limit}
+		return 0, b.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// 多读 1 字节以判断是否超出上限
+	room := b.limit - b.read
+	if int64(len(p)) > room+1 {
+		p = p[:room+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= room {
+		b.read += int64(n)
+		return n, err
+	}
+	b.read += room
+	b.err = &http.MaxBytesError{Limit: b.limit}
+	return int(room), b.err
+}
+
+// bodyLimitWriter 请求体超限后将处理函数的 400 响应替换为 413。
+type bodyLimitWriter struct {
+	gin.ResponseWriter
+	body     *limitedBody
+	replaced bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.replaced {
+		return
+	}
+	if code == http.StatusBadRequest && w.body.err != nil && !w.ResponseWriter.Written() {
+		w.replaced = true
+		// c.Writer 即当前写入器，使用独立的 Context 写入 413 响应
+		response.PayloadTooLarge(&gin.Context{Writer: w.ResponseWriter})
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyLimitWriter) WriteHeaderNow() {
+	if !w.replaced {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bodyLimitWriter) Write(p []byte) (int, error) {
+	if w.replaced {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyLimitWriter) WriteString(s string) (int, error) {
+	if w.replaced {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// readAll 读取完整请求体，失败时按 c.ShouldBindJSON 的习惯返回 400。
+	readAll := func(c *gin.Context) {
+		b, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%d", len(b))
+	}
+	// wrap 包装处理函数，使其名称不再是 RouteBodyLimit
+	wrap := func(h gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) { h(c) }
+	}
+
+	tests := []struct {
+		name          string
+		global        BodyLimitConfig
+		route         []gin.HandlerFunc
+		size          int
+		unknownLength bool
+		contentType   string
+		want          int
+	}{
+		{name: "within limit", global: BodyLimitConfig{Limit: 10}, size: 10, want: http.StatusOK},
+		{name: "over limit", global: BodyLimitConfig{Limit: 10}, size: 11, want: http.StatusRequestEntityTooLarge},
+		{name: "over limit with unknown length", global: BodyLimitConfig{Limit: 10}, size: 11, unknownLength: true, want: http.StatusRequestEntityTooLarge},
+		{name: "unlimited", global: BodyLimitConfig{Limit: -1}, size: 1 << 10, want: http.StatusOK},
+		{name: "multipart limit", global: BodyLimitConfig{Limit: 10, MultipartLimit: 20}, size: 20, contentType: "multipart/form-data; boundary=x", want: http.StatusOK},
+		{name: "route raises limit", global: BodyLimitConfig{Limit: 10}, route: []gin.HandlerFunc{RouteBodyLimit(100)}, size: 50, want: http.StatusOK},
+		{name: "wrapped route override still wins", global: BodyLimitConfig{Limit: 10}, route: []gin.HandlerFunc{wrap(RouteBodyLimit(100))}, size: 50, want: http.StatusOK},
+		{name: "route override removes limit", global: BodyLimitConfig{Limit: 10}, route: []gin.HandlerFunc{RouteBodyLimit(-1)}, size: 50, want: http.StatusOK},
+		{name: "route lowers limit", global: BodyLimitConfig{Limit: 100}, route: []gin.HandlerFunc{RouteBodyLimit(10)}, size: 50, want: http.StatusRequestEntityTooLarge},
+		{name: "raised route limit still enforced", global: BodyLimitConfig{Limit: 10}, route: []gin.HandlerFunc{RouteBodyLimit(20)}, size: 21, unknownLength: true, want: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(BodyLimitWithConfig(tt.global))
+			r.POST("/", append(tt.route, readAll)...)
+
+			var body io.Reader = strings.NewReader(strings.Repeat("x", tt.size))
+			if tt.unknownLength {
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+)
+
+// DefaultBodyLogMaxSize 请求/响应体的默认记录上限（字节）。
+const DefaultBodyLogMaxSize = 4 << 10
+
+// DefaultBodyLogContentTypes 默认记录的内容类型。
+var DefaultBodyLogContentTypes = []string{
+	"application/json", "application/*+json", "application/x-www-form-urlencoded", "text/plain",
+}
+
+// DefaultRedactFields 默认脱敏的 JSON 字段。
+var DefaultRedactFields = []string{
+	"password", "old_password", "new_password", "token", "access_token", "refresh_token", "secret",
+}
+
+// BodyLogConfig 请求/响应体记录配置。
+type BodyLogConfig struct {
+	// Request 记录请求体。
+	Request bool
+
+	// Response 记录响应体。
+	Response bool
+
+	// MaxSize 每个请求体/响应体记录的最大字节数，默认 4KB，超出部分截断。
+	MaxSize int
+
+	// ContentTypes 记录的内容类型，支持 type/* 与 application/*+json 形式，
+	// 默认 [DefaultBodyLogContentTypes]。其他类型（文件上传、二进制等）不记录。
+	ContentTypes []string
+
+	// RedactFields 脱敏的字段路径，默认 [DefaultRedactFields]：
+	//   - 含点的路径从根开始匹配，如 card.number（数组元素逐个匹配）
+	//   - 不含点的名称匹配任意层级的同名字段，如 password
+	// 表单请求体按字段名（路径的最后一段）脱敏。
+	RedactFields []string
+
+	// PerRoute 仅记录启用了 [LogBody] 的路由（如 routes.Route.LogBody 为 true）。
+	PerRoute bool
+}
+
+// bodyLogKey 是请求体记录状态在 Gin context 中的键名。
+const bodyLogKey = "body_log"
+
+// LogBody 为当前路由启用请求/响应体记录。
+//
+// 需配合 LoggerConfig.Body（PerRoute 为 true）使用，必须位于访问日志中间件之后、处理函数之前；
+// 声明式路由设置 routes.Route.LogBody 即可自动添加。
+func LogBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bc, ok := ctxutil.Get[*bodyCapture](c, bodyLogKey); ok {
+			bc.start(c)
+		}
+		c.Next()
+	}
+}
+
+// bodyCapture 单个请求的请求/响应体记录状态。
+type bodyCapture struct {
+	cfg     *BodyLogConfig
+	types   []string
+	redact  fieldRedactor
+	started bool
+
+	request     []byte
+	requestType string
+	requestCut  bool
+	response    *bodyWriter
+}
+
+// newBodyCapture 返回按配置构造记录状态的函数，未启用时返回 nil。
+func newBodyCapture(c *BodyLogConfig) func() *bodyCapture {
+	if c == nil || (!c.Request && !c.Response) {
+		return nil
+	}
+	cfg := *c
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultBodyLogMaxSize
+	}
+	if cfg.ContentTypes == nil {
+		cfg.ContentTypes = DefaultBodyLogContentTypes
+	}
+	if cfg.RedactFields == nil {
+		cfg.RedactFields = DefaultRedactFields
+	}
+	redact := newFieldRedactor(cfg.RedactFields)
+	return func() *bodyCapture {
+		return &bodyCapture{cfg: &cfg, types: cfg.ContentTypes, redact: redact}
+	}
+}
+
+// start 预读请求体（处理函数仍可完整读取）并包装响应写入器。
+func (bc *bodyCapture) start(c *gin.Context) {
+	if bc.started {
+		return
+	}
+	bc.started = true
+
+	if bc.cfg.Request && c.Request.Body != nil && c.Request.Body != http.NoBody {
+		if ct := c.ContentType(); matchContentType(ct, bc.types) {
+			body := c.Request.Body
+			buf, _ := io.ReadAll(io.LimitReader(body, int64(bc.cfg.MaxSize)+1))
+			c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), body), Closer: body}
+
+			bc.requestType = ct
+			bc.requestCut = len(buf) > bc.cfg.MaxSize
+			bc.request = buf[:min(len(buf), bc.cfg.MaxSize)]
+		}
+	}
+
+	if bc.cfg.Response {
+		bc.response = &bodyWriter{ResponseWriter: c.Writer, capture: bc}
+		c.Writer = bc.response
+	}
+}
+
+// attrs 返回记录的请求/响应体属性。
+func (bc *bodyCapture) attrs() []slog.Attr {
+	var attrs []slog.Attr
+	if bc.request != nil {
+		attrs = append(attrs, slog.String("req_body", bc.format(bc.request, bc.requestType, bc.requestCut)))
+	}
+	if w := bc.response; w != nil && w.state == captureActive && w.buf.Len() > 0 {
+		attrs = append(attrs, slog.String("resp_body", bc.format(w.buf.Bytes(), w.Header().Get("Content-Type"), w.cut)))
+	}
+	return attrs
+}
+
+// format 脱敏并格式化请求/响应体。
+func (bc *bodyCapture) format(body []byte, contentType string, truncated bool) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		s := redactQueryString(string(body), bc.redact.names)
+		if truncated {
+			s += "...(truncated)"
+		}
+		return s
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		if truncated {
+			// 截断的 JSON 无法解析，无法保证脱敏，整体省略
+			return "[OMITTED: truncated JSON]"
+		}
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return "[OMITTED: invalid JSON]"
+		}
+		out, err := json.Marshal(bc.redact.apply(v, nil))
+		if err != nil {
+			return "[OMITTED: invalid JSON]"
+		}
+		return string(out)
+	default:
+		if truncated {
+			return string(body) + "...(truncated)"
+		}
+		return string(body)
+	}
+}
+
+// readCloser 组合预读后的 Reader 与原始请求体的 Closer。
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ============================================================================
+// 响应体捕获
+// ============================================================================
+
+// 响应体捕获状态。
+const (
+	captureUndecided = iota // 尚未写入，未确定内容类型
+	captureActive           // 记录中
+	captureDisabled         // 内容类型不匹配或流式响应，不记录
+)
+
+// bodyWriter 在写入响应的同时复制前 MaxSize 字节，不缓冲响应本身。
+type bodyWriter struct {
+	gin.ResponseWriter
+	capture *bodyCapture
+	buf     bytes.Buffer
+	cut     bool
+	state   int
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush 流式响应（SSE 等）不记录，丢弃已复制的内容。
+func (w *bodyWriter) Flush() {
+	w.state = captureDisabled
+	w.buf = bytes.Buffer{}
+	w.ResponseWriter.Flush()
+}
+
+func (w *bodyWriter) record(b []byte) {
+	if w.state == captureUndecided {
+		w.state = captureDisabled
+		if matchContentType(w.Header().Get("Content-Type"), w.capture.types) {
+			w.state = captureActive
+		}
+	}
+	if w.state != captureActive {
+		return
+	}
+	room := w.capture.cfg.MaxSize - w.buf.Len()
+	if len(b) > room {
+		b = b[:max(room, 0)]
+		w.cut = true
+	}
+	w.buf.Write(b)
+}
+
+// ============================================================================
+// 内容类型与字段脱敏
+// ============================================================================
+
+// matchContentType 报告内容类型是否在允许列表中。
+func matchContentType(contentType string, allowed []string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mt == "text/event-stream" {
+		return false
+	}
+	for _, a := range allowed {
+		switch {
+		case a == mt:
+			return true
+		case strings.HasSuffix(a, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a, "*")):
+			return true
+		case strings.HasPrefix(a, "application/*+") && strings.HasPrefix(mt, "application/") &&
+			strings.HasSuffix(mt, strings.TrimPrefix(a, "application/*")):
+			return true
+		}
+	}
+	return false
+}
+
+// fieldRedactor JSON 字段脱敏规则。
+type fieldRedactor struct {
+	paths    [][]string      // 从根开始匹配的路径
+	anywhere map[string]bool // 任意层级匹配的字段名
+	names    map[string]bool // 全部字段名（表单脱敏使用）
+}
+
+func newFieldRedactor(fields []string) fieldRedactor {
+	r := fieldRedactor{anywhere: make(map[string]bool), names: make(map[string]bool)}
+	for _, f := range fields {
+		segs := strings.Split(strings.ToLower(f), ".")
+		r.names[segs[len(segs)-1]] = true
+		if len(segs) == 1 {
+			r.anywhere[segs[0]] = true
+		} else {
+			r.paths = append(r.paths, segs)
+		}
+	}
+	return r
+}
+
+// apply 递归脱敏，path 为当前值的路径。
+func (r fieldRedactor) apply(v any, path []string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			p := append(path[:len(path):len(path)], strings.ToLower(k))
+			if r.match(p) {
+				t[k] = Redacted
+				continue
+			}
+			t[k] = r.apply(child, p)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = r.apply(child, path) // 数组元素沿用父路径
+		}
+	}
+	return v
+}
+
+// match 报告路径是否需要脱敏。
+func (r fieldRedactor) match(path []string) bool {
+	if r.anywhere[path[len(path)-1]] {
+		return true
+	}
+	for _, p := range r.paths {
+		if slices.Equal(p, path) {
+			return true
+		}
+	}
+	return false
+}
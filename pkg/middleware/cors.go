@@ -1,35 +1,253 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
 )
 
-// CORS 创建跨域资源共享中间件。
+// CORSConfig 跨域资源共享中间件配置。
+type CORSConfig struct {
+	// AllowOrigins 允许的来源列表，支持：
+	//   - 精确匹配：https://app.example.com
+	//   - 子域名通配：https://*.example.com（匹配任意层级子域名，不匹配 example.com 本身）
+	//   - 任意来源：*
+	//
+	// 其他位置的 *（如缺少协议的 *.example.com）视为配置错误，创建中间件时 panic。
+	AllowOrigins []string
+
+	// AllowOriginFunc 自定义来源判断，在 AllowOrigins 未命中时调用（可选）。
+	AllowOriginFunc func(origin string) bool
+
+	// AllowMethods 预检允许的方法（大小写不敏感）。默认 GET、POST、PUT、PATCH、DELETE、HEAD、OPTIONS。
+	AllowMethods []string
+
+	// AllowHeaders 预检允许的请求头（大小写不敏感）。
+	// 为空时回显预检请求的 Access-Control-Request-Headers。
+	AllowHeaders []string
+
+	// ExposeHeaders 允许浏览器脚本读取的响应头，如 X-Request-ID。
+	ExposeHeaders []string
+
+	// AllowCredentials 允许携带 Cookie 等凭证。
+	// 启用时即使配置了 *，也会回显具体来源（浏览器拒绝 * 与凭证同时出现）。
+	AllowCredentials bool
+
+	// MaxAge 预检结果的缓存时间，0 表示不设置。
+	MaxAge time.Duration
+
+	// AllowPrivateNetwork 响应私有网络访问预检
+	// （Access-Control-Request-Private-Network: true）。
+	AllowPrivateNetwork bool
+
+	// AnswerOptions 非预检的 OPTIONS 请求（含无 Origin 头的请求）直接返回 204，不执行后续处理链。
+	// [CORS] 默认启用，与旧版本行为一致；未启用时交由路由处理。
+	AnswerOptions bool
+}
+
+// defaultCORSMethods 默认允许的方法。
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// CORS 创建跨域资源共享中间件（宽松的默认配置）。
 //
 // 默认配置：
 //   - Allow-Origin: *
-//   - Allow-Methods: POST, GET, PUT, DELETE, PATCH, OPTIONS
-//   - Allow-Headers: Content-Type, Authorization, X-Requested-With
-//   - Allow-Credentials: true
+//   - Allow-Methods: GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS
+//   - Allow-Headers: Content-Type, Authorization, X-Requested-With 等常用请求头
+//   - 不允许携带凭证（浏览器拒绝 * 与 Allow-Credentials: true 同时出现）
+//   - 所有 OPTIONS 请求直接返回 204（AnswerOptions）
 //
-// 对于 OPTIONS 预检请求，直接返回 204 No Content。
+// 需要携带凭证或限制来源时使用 [CORSWithConfig]。
 func CORS() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers",
-			"Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, "+
-				"Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods",
-			"POST, OPTIONS, GET, PUT, DELETE, PATCH")
-
-		if c.Request.Method == http.MethodOptions {
+	return CORSWithConfig(CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowHeaders: []string{
+			"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token",
+			"Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With",
+		},
+		AnswerOptions: true,
+	})
+}
+
+// CORSWithConfig 创建跨域资源共享中间件。
+//
+// 处理流程：
+//   - 无 Origin 头的请求不是跨域请求，直接放行
+//   - 来源命中时回显该来源并附带 Vary: Origin（仅在配置 * 且不允许凭证时返回 *）
+//   - 预检请求（OPTIONS + Access-Control-Request-Method）：
+//     来源、方法或请求头不被允许时返回 403，否则返回 204
+//   - 来源未命中的普通请求照常处理但不附带 CORS 头，由浏览器拦截响应
+//   - 启用 AnswerOptions 时，非预检的 OPTIONS 请求返回 204
+//
+// 示例：
+//
+//	r.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+//	    AllowOrigins:     []string{"https://app.example.com", "https://*.example.dev"},
+//	    AllowCredentials: true,
+//	    ExposeHeaders:    []string{middleware.RequestIDHeader},
+//	    MaxAge:           12 * time.Hour,
+//	}))
+func CORSWithConfig(cfg CORSConfig) gin.HandlerFunc {
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = defaultCORSMethods
+	}
+	allowMethods := make([]string, len(cfg.AllowMethods))
+	for i, m := range cfg.AllowMethods {
+		allowMethods[i] = strings.ToUpper(strings.TrimSpace(m))
+	}
+
+	var (
+		anyOrigin bool
+		exact     = make(map[string]bool)
+		wildcards []originPattern
+	)
+	for _, o := range cfg.AllowOrigins {
+		o = strings.ToLower(strings.TrimSpace(o))
+		switch {
+		case o == "*":
+			anyOrigin = true
+		case strings.Contains(o, "://*."):
+			scheme, host, _ := strings.Cut(o, "://*")
+			if scheme == "" || strings.Contains(host, "*") {
+				panic(fmt.Sprintf("middleware: invalid CORS origin pattern %q", o))
+			}
+			wildcards = append(wildcards, originPattern{prefix: scheme + "://", suffix: host})
+		case strings.Contains(o, "*"):
+			panic(fmt.Sprintf("middleware: invalid CORS origin pattern %q, want scheme://*.host", o))
+		default:
+			exact[o] = true
+		}
+	}
+
+	allowed := func(origin string) bool {
+		lower := strings.ToLower(origin)
+		if anyOrigin || exact[lower] {
+			return true
+		}
+		for _, p := range wildcards {
+			if p.match(lower) {
+				return true
+			}
+		}
+		return cfg.AllowOriginFunc != nil && cfg.AllowOriginFunc(origin)
+	}
+
+	methods := strings.Join(allowMethods, ", ")
+	headers := strings.Join(cfg.AllowHeaders, ", ")
+	expose := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+	wildcardOrigin := anyOrigin && !cfg.AllowCredentials
+
+	// next 执行后续处理链，AnswerOptions 时直接响应 OPTIONS 请求
+	next := func(c *gin.Context) {
+		if cfg.AnswerOptions && c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
-
 		c.Next()
 	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			next(c)
+			return
+		}
+
+		h := c.Writer.Header()
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !wildcardOrigin {
+			h.Add("Vary", "Origin")
+		}
+
+		if !allowed(origin) {
+			if preflight {
+				response.Forbidden(c, "CORS origin not allowed")
+				c.Abort()
+				return
+			}
+			next(c)
+			return
+		}
+
+		if wildcardOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if expose != "" {
+				h.Set("Access-Control-Expose-Headers", expose)
+			}
+			next(c)
+			return
+		}
+
+		// 预检请求
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+
+		method := strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))
+		if !slices.Contains(allowMethods, method) {
+			response.Forbidden(c, "CORS method not allowed")
+			c.Abort()
+			return
+		}
+
+		requested := c.GetHeader("Access-Control-Request-Headers")
+		if len(cfg.AllowHeaders) == 0 {
+			if requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+		} else {
+			for name := range strings.SplitSeq(requested, ",") {
+				name = strings.TrimSpace(name)
+				if name != "" && !slices.ContainsFunc(cfg.AllowHeaders, func(a string) bool { return strings.EqualFold(a, name) }) {
+					response.Forbidden(c, "CORS header not allowed")
+					c.Abort()
+					return
+				}
+			}
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+
+		h.Set("Access-Control-Allow-Methods", methods)
+		if maxAge != "" {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		if cfg.AllowPrivateNetwork && c.GetHeader("Access-Control-Request-Private-Network") == "true" {
+			h.Set("Access-Control-Allow-Private-Network", "true")
+		}
+
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// originPattern 子域名通配来源，如 https://*.example.com。
+type originPattern struct {
+	prefix string // 协议部分，如 https://
+	suffix string // 域名后缀（含前导点），如 .example.com
+}
+
+// match 报告来源是否为后缀域名的子域名（至少一级）。
+func (p originPattern) match(origin string) bool {
+	host, ok := strings.CutPrefix(origin, p.prefix)
+	return ok && len(host) > len(p.suffix) && strings.HasSuffix(host, p.suffix)
 }
@@ -2,11 +2,74 @@ package middleware
 
 import (
 	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Redacted 是脱敏后的占位值。
+const Redacted = "[REDACTED]"
+
+// DefaultRedactHeaders 默认脱敏的请求头。
+var DefaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-CSRF-Token",
+}
+
+// DefaultRedactQuery 默认脱敏的查询参数。
+var DefaultRedactQuery = []string{
+	"token", "access_token", "refresh_token", "id_token", "password", "secret", "api_key", "apikey", "signature",
+}
+
+// LoggerConfig 访问日志中间件配置。
+type LoggerConfig struct {
+	// Logger 输出日志的 logger，默认 slog.Default()。
+	Logger *slog.Logger
+
+	// Message 日志消息，默认 "HTTP Request"。
+	Message string
+
+	// SkipPaths 跳过的路径（精确匹配），如 /health。
+	SkipPaths []string
+
+	// SkipPrefixes 跳过的路径前缀，如 /static/。
+	SkipPrefixes []string
+
+	// SkipPattern 跳过匹配该正则的路径。
+	SkipPattern *regexp.Regexp
+
+	// Skip 自定义跳过条件，在请求处理完成后调用，可根据状态码判断。
+	Skip func(c *gin.Context) bool
+
+	// Headers 记录的请求头（大小写不敏感），默认不记录。
+	// 命中 RedactHeaders 的请求头以 [Redacted] 代替。
+	Headers []string
+
+	// RedactHeaders 脱敏的请求头，默认 [DefaultRedactHeaders]。
+	RedactHeaders []string
+
+	// RedactQuery 脱敏的查询参数（大小写不敏感），默认 [DefaultRedactQuery]。
+	RedactQuery []string
+
+	// SlowThreshold 慢请求阈值，超过时至少以 WARN 级别记录并附带 slow=true，0 表示不启用。
+	SlowThreshold time.Duration
+
+	// SuccessSampleRate 2xx 请求的采样率（0~1），0 或 ≥1 表示全部记录。
+	// 慢请求、重定向（3xx）与错误请求总是记录。
+	SuccessSampleRate float64
+
+	// Level 按状态码返回日志级别，默认 5xx ERROR、4xx WARN、其他 INFO。
+	Level func(status int) slog.Level
+
+	// Body 请求/响应体记录配置，nil 表示不记录。
+	// 会增加内存与日志量，建议配合 PerRoute 只对需要排查的路由启用。
+	Body *BodyLogConfig
+}
+
 // Logger 返回一个基于 slog 的 Gin 日志中间件
 //
 // 记录每个 HTTP 请求的详细信息，包括：
@@ -19,54 +82,10 @@ import (
 // - 3xx: INFO
 // - 4xx: WARN
 // - 5xx: ERROR
+//
+// 详见 [LoggerWithConfig]。
 func Logger() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 记录请求开始时间
-		start := time.Now()
-		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
-
-		// 处理请求
-		c.Next()
-
-		// 计算请求处理时间
-		latency := time.Since(start)
-		statusCode := c.Writer.Status()
-		method := c.Request.Method
-		clientIP := c.ClientIP()
-		userAgent := c.Request.UserAgent()
-
-		// 构建日志字段
-		fields := []any{
-			"method", method,
-			"path", path,
-			"status", statusCode,
-			"latency", latency.String(),
-			"ip", clientIP,
-			"user_agent", userAgent,
-		}
-
-		// 如果有查询参数，添加到日志
-		if query != "" {
-			fields = append(fields, "query", query)
-		}
-
-		// 如果有错误，添加到日志
-		if len(c.Errors) > 0 {
-			fields = append(fields, "errors", c.Errors.String())
-		}
-
-		// 根据状态码选择日志级别
-		msg := "HTTP Request"
-		switch {
-		case statusCode >= 500:
-			slog.Error(msg, fields...)
-		case statusCode >= 400:
-			slog.Warn(msg, fields...)
-		default:
-			slog.Info(msg, fields...)
-		}
-	}
+	return LoggerWithConfig(LoggerConfig{})
 }
 
 // LoggerSkipPaths 返回一个跳过指定路径的日志中间件
@@ -80,57 +99,207 @@ func Logger() gin.HandlerFunc {
 //
 //	r.Use(middleware.LoggerSkipPaths("/health", "/metrics"))
 func LoggerSkipPaths(skipPaths ...string) gin.HandlerFunc {
-	skipPathsMap := make(map[string]bool, len(skipPaths))
-	for _, path := range skipPaths {
-		skipPathsMap[path] = true
+	return LoggerWithConfig(LoggerConfig{SkipPaths: skipPaths})
+}
+
+// LoggerWithConfig 创建访问日志中间件。
+//
+// 每个请求在处理完成后输出一条日志，字段包括：
+//   - method、path、status、latency_ms（数值，毫秒）、ip、user_agent
+//   - query（已脱敏）、req_size、resp_size
+//   - request_id、operation（存在时）
+//   - headers（配置 Headers 时，已脱敏）、errors（c.Errors 非空时）、slow（慢请求）
+//   - req_body、resp_body（配置 Body 时，已脱敏；流式响应不记录）
+//
+// 示例：
+//
+//	r.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+//	    SkipPrefixes:      []string{"/static/"},
+//	    SkipPaths:         []string{"/health"},
+//	    Headers:           []string{"Authorization", "X-Forwarded-For"},
+//	    SlowThreshold:     time.Second,
+//	    SuccessSampleRate: 0.1,
+//	}))
+func LoggerWithConfig(cfg LoggerConfig) gin.HandlerFunc {
+	if cfg.Message == "" {
+		cfg.Message = "HTTP Request"
+	}
+	if cfg.RedactHeaders == nil {
+		cfg.RedactHeaders = DefaultRedactHeaders
+	}
+	if cfg.RedactQuery == nil {
+		cfg.RedactQuery = DefaultRedactQuery
+	}
+	if cfg.Level == nil {
+		cfg.Level = defaultLogLevel
+	}
+
+	skipPaths := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skipPaths[path] = true
+	}
+	redactHeaders := lowerSet(cfg.RedactHeaders)
+	redactQuery := lowerSet(cfg.RedactQuery)
+	newCapture := newBodyCapture(cfg.Body)
+
+	skipPath := func(path string) bool {
+		if skipPaths[path] {
+			return true
+		}
+		for _, prefix := range cfg.SkipPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return cfg.SkipPattern != nil && cfg.SkipPattern.MatchString(path)
 	}
 
 	return func(c *gin.Context) {
 		path := c.Request.URL.Path
 
 		// 如果路径在跳过列表中，直接继续处理
-		if skipPathsMap[path] {
+		if skipPath(path) {
 			c.Next()
 			return
 		}
 
-		// 否则执行日志记录
 		start := time.Now()
 		query := c.Request.URL.RawQuery
+		reqSize := c.Request.ContentLength
+
+		var capture *bodyCapture
+		if newCapture != nil {
+			capture = newCapture()
+			c.Set(bodyLogKey, capture)
+			if !cfg.Body.PerRoute {
+				capture.start(c)
+			}
+		}
 
 		c.Next()
 
+		if cfg.Skip != nil && cfg.Skip(c) {
+			return
+		}
+
 		latency := time.Since(start)
-		statusCode := c.Writer.Status()
-		method := c.Request.Method
-		clientIP := c.ClientIP()
-		userAgent := c.Request.UserAgent()
+		status := c.Writer.Status()
+		slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+
+		level := cfg.Level(status)
+		if slow && level < slog.LevelWarn {
+			level = slog.LevelWarn
+		}
+
+		// 采样：仅对未升级的 2xx 请求生效
+		if status >= http.StatusOK && status < http.StatusMultipleChoices && !slow && cfg.SuccessSampleRate > 0 && cfg.SuccessSampleRate < 1 &&
+			rand.Float64() >= cfg.SuccessSampleRate {
+			return
+		}
+
+		logger := cfg.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		if !logger.Enabled(c.Request.Context(), level) {
+			return
+		}
 
-		fields := []any{
-			"method", method,
-			"path", path,
-			"status", statusCode,
-			"latency", latency.String(),
-			"ip", clientIP,
-			"user_agent", userAgent,
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(latency)/float64(time.Millisecond)),
+			slog.String("ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int64("req_size", max(reqSize, 0)),
+			slog.Int("resp_size", max(c.Writer.Size(), 0)),
 		}
 
+		// 如果有查询参数，脱敏后添加到日志
 		if query != "" {
-			fields = append(fields, "query", query)
+			attrs = append(attrs, slog.String("query", redactQueryString(query, redactQuery)))
+		}
+		// ContextHandler 会从 context 附加 request_id 等属性，避免重复
+		if _, ok := logger.Handler().(*ContextHandler); !ok {
+			if id := GetRequestID(c); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			if op := GetOperationID(c); op != "" {
+				attrs = append(attrs, slog.String("operation", op))
+			}
+		}
+		if len(cfg.Headers) > 0 {
+			attrs = append(attrs, headerAttrs(c.Request.Header, cfg.Headers, redactHeaders))
+		}
+		if capture != nil {
+			attrs = append(attrs, capture.attrs()...)
+		}
+		if slow {
+			attrs = append(attrs, slog.Bool("slow", true))
 		}
 
+		// 如果有错误，添加到日志
 		if len(c.Errors) > 0 {
-			fields = append(fields, "errors", c.Errors.String())
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
 		}
 
-		msg := "HTTP Request"
-		switch {
-		case statusCode >= 500:
-			slog.Error(msg, fields...)
-		case statusCode >= 400:
-			slog.Warn(msg, fields...)
-		default:
-			slog.Info(msg, fields...)
+		logger.LogAttrs(c, level, cfg.Message, attrs...)
+	}
+}
+
+// defaultLogLevel 根据状态码选择日志级别。
+func defaultLogLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// headerAttrs 返回记录的请求头分组，脱敏命中的请求头。
+func headerAttrs(h http.Header, names []string, redact map[string]bool) slog.Attr {
+	attrs := make([]any, 0, len(names))
+	for _, name := range names {
+		v := h.Get(name)
+		if v == "" {
+			continue
 		}
+		if redact[strings.ToLower(name)] {
+			v = Redacted
+		}
+		attrs = append(attrs, slog.String(strings.ToLower(name), v))
+	}
+	return slog.Group("headers", attrs...)
+}
+
+// redactQueryString 将敏感查询参数的值替换为 [Redacted]，保持参数顺序。
+func redactQueryString(raw string, redact map[string]bool) string {
+	if len(redact) == 0 {
+		return raw
+	}
+	parts := strings.Split(raw, "&")
+	for i, part := range parts {
+		key, _, hasValue := strings.Cut(part, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if hasValue && redact[strings.ToLower(name)] {
+			parts[i] = key + "=" + Redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// lowerSet 构造小写集合。
+func lowerSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[strings.ToLower(item)] = true
 	}
+	return set
 }
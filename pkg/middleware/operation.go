@@ -14,9 +14,12 @@ const OperationIDKey = "operation_id"
 //
 // Operation ID 格式：{domain}.{resource}.{action}
 // 例如：admin.users.create, user.profile.get
+//
+// 位于 [TimeoutWithConfig] 之后时，按 TimeoutConfig.Operations 调整超时时间。
 func SetOperationID(operationID string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Set(OperationIDKey, operationID)
+		applyOperationTimeout(c, operationID)
 		c.Next()
 	}
 }
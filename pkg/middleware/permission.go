@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/permission"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// PermissionConfig 权限校验中间件配置。
+type PermissionConfig struct {
+	// Permissions 返回当前请求被授予的操作模式列表。
+	// 第二个返回值为 false 表示请求未认证。
+	// 默认从 ctxutil.Permissions 读取 []string。
+	Permissions func(c *gin.Context) ([]string, bool)
+
+	// Resolver 返回当前请求的变量解析器。
+	// 默认使用 ctxutil.UserID、ctxutil.OrgID、ctxutil.TeamID 构造 @me、@org、@team。
+	Resolver func(c *gin.Context) *permission.Resolver
+
+	// Store 可热加载的策略存储（可选）。
+	// 设置后，ctxutil.Roles 中的角色会按当前快照展开为授权模式（带条件的角色按请求属性求值），
+	// 快照中策略的 deny 语句作为全局护栏参与评估；allow 语句不绑定主体，不会授予权限。
+	// 每个请求只读取一次快照，并存入 PolicySnapshotKey 供后续处理器使用。
+	Store *permission.PolicyStore
+
+	// Attributes 返回角色条件与快照策略条件使用的请求属性。
+	// 默认包含 subject.id/org/team（来自 ctxutil）与 env.ip/time。
+	Attributes func(c *gin.Context) permission.Attributes
+
+	// Resource 返回当前请求访问的资源及其属性（可选），仅在设置 Store 时使用，
+	// 如根据路径参数加载记录并返回 {"owner": ..., "status": ...}。
+	// 资源用于匹配 deny 语句的 Resources，属性合并到 Attributes 的 resource 下供条件使用。
+	// 未设置时限定了 Resources 的语句不命中，引用 resource 属性的角色条件不成立。
+	// 返回错误时响应 500 并中止请求。
+	Resource func(c *gin.Context) (permission.Resource, map[string]any, error)
+
+	// Explain 拒绝时在 403 响应的 error 字段附带脱敏后的 [permission.Explanation]。
+	// 仅在 gin debug 模式下生效，生产环境（release 模式）自动忽略。
+	Explain bool
+}
+
+// PolicySnapshotKey 是当前请求使用的策略快照在 context 中的键名。
+const PolicySnapshotKey = "policy_snapshot"
+
+// GetPolicySnapshot 从 Gin context 获取当前请求使用的策略快照。
+// 如果不存在返回 nil。
+func GetPolicySnapshot(c *gin.Context) *permission.Snapshot {
+	snap, _ := ctxutil.Get[*permission.Snapshot](c, PolicySnapshotKey)
+	return snap
+}
+
+// RequirePermission 创建权限校验中间件（默认配置）。
+//
+// 必须放在 [SetOperationID] 与认证中间件之后。
+// 详见 [RequirePermissionWithConfig]。
+func RequirePermission() gin.HandlerFunc {
+	return RequirePermissionWithConfig(PermissionConfig{})
+}
+
+// RequirePermissionWithConfig 创建权限校验中间件。
+//
+// 处理流程：
+//   - 公开操作（scope 为 public）直接放行
+//   - 未设置 Operation ID 的路由返回 403（默认拒绝）
+//   - 未认证（无授权列表）返回 401
+//   - 解析授权模式中的 @me/@org/@team 后逐一匹配当前操作，无命中返回 403
+//     （变量缺失或取值不安全的模式会被跳过，见 [permission.Resolver.ResolveStrict]）
+//
+// 设置 Store 时，未认证的判断同时考虑 ctxutil.Roles；角色按请求属性展开，
+// 条件不成立的角色不授予任何模式。快照策略中的 deny 语句命中时直接返回 403，
+// 条件因属性缺失无法求值时 deny 语句视为命中，见 [permission.Statement.MatchesAttributes]。
+func RequirePermissionWithConfig(cfg PermissionConfig) gin.HandlerFunc {
+	if cfg.Permissions == nil {
+		cfg.Permissions = defaultPermissions
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = defaultResolver
+	}
+	if cfg.Attributes == nil {
+		cfg.Attributes = defaultAttributes
+	}
+
+	return func(c *gin.Context) {
+		op := permission.Operation(GetOperationID(c))
+		if op.IsPublic() {
+			c.Next()
+			return
+		}
+
+		if op == "" {
+			cfg.forbid(c, op, nil, "operation id not set")
+			return
+		}
+
+		grants, ok := cfg.Permissions(c)
+
+		if cfg.Store != nil {
+			snap := cfg.Store.Snapshot()
+			c.Set(PolicySnapshotKey, snap)
+
+			roles, hasRoles := ctxutil.Get[[]string](c, ctxutil.Roles)
+			if hasRoles {
+				ok = true
+			}
+
+			if ok {
+				res, attrs, err := cfg.requestAttributes(c)
+				if err != nil {
+					_ = c.Error(fmt.Errorf("permission resource: %w", err))
+					response.InternalError(c)
+					c.Abort()
+					return
+				}
+				if hasRoles {
+					grants = append(grants[:len(grants):len(grants)], snap.GrantsWithAttributes(attrs, roles...).Operations...)
+				}
+
+				// 快照策略是全局护栏：只有 deny 生效，allow 不会单独授予权限
+				if d := snap.EvaluateWithAttributes(op, res, attrs); d.Reason == permission.ReasonExplicitDeny {
+					cfg.forbid(c, op, grants, "policy: "+d.String())
+					return
+				}
+			}
+		}
+
+		if !ok {
+			response.Unauthorized(c)
+			c.Abort()
+			return
+		}
+
+		r := cfg.Resolver(c)
+		for _, pattern := range grants {
+			// 变量无法安全解析的模式直接跳过，避免注入或误匹配
+			resolved, err := r.ResolveStrict(pattern)
+			if err != nil {
+				continue
+			}
+			if permission.MatchOperation(resolved, string(op)) {
+				c.Next()
+				return
+			}
+		}
+
+		cfg.forbid(c, op, grants, "")
+	}
+}
+
+// requestAttributes 返回当前请求的资源与属性（资源属性合并到 resource 下）。
+func (cfg *PermissionConfig) requestAttributes(c *gin.Context) (permission.Resource, permission.Attributes, error) {
+	attrs := cfg.Attributes(c)
+	if cfg.Resource == nil {
+		return "", attrs, nil
+	}
+	res, resAttrs, err := cfg.Resource(c)
+	if err != nil {
+		return "", nil, err
+	}
+	if resAttrs != nil {
+		attrs = maps.Clone(attrs)
+		if attrs == nil {
+			attrs = permission.Attributes{}
+		}
+		attrs[permission.AttrResource] = resAttrs
+	}
+	return res, attrs, nil
+}
+
+// forbid 返回 403 并中止请求，按配置附带权限判定说明。
+func (cfg *PermissionConfig) forbid(c *gin.Context, op permission.Operation, grants []string, reason string) {
+	defer c.Abort()
+	if !cfg.Explain || !gin.IsDebugging() {
+		response.Forbidden(c)
+		return
+	}
+	e := permission.Explain(string(op), grants, cfg.Resolver(c)).Redact()
+	if reason != "" {
+		e.Allowed = false
+		e.Reason = reason
+	}
+	response.ForbiddenWithDetails(c, e)
+}
+
+// defaultPermissions 从 ctxutil.Permissions 读取授权模式列表。
+func defaultPermissions(c *gin.Context) ([]string, bool) {
+	return ctxutil.Get[[]string](c, ctxutil.Permissions)
+}
+
+// defaultResolver 根据 context 中的用户、组织、团队 ID 构造解析器。
+func defaultResolver(c *gin.Context) *permission.Resolver {
+	vars := make(map[string]string, 3)
+	for key, name := range map[string]string{
+		ctxutil.UserID: permission.VarMe,
+		ctxutil.OrgID:  permission.VarOrg,
+		ctxutil.TeamID: permission.VarTeam,
+	} {
+		if v, ok := c.Get(key); ok && v != nil {
+			vars[name] = fmt.Sprint(v)
+		}
+	}
+	return permission.NewResolver(vars)
+}
+
+// defaultAttributes 根据 context 构造条件求值使用的请求属性。
+func defaultAttributes(c *gin.Context) permission.Attributes {
+	subject := make(map[string]any, 3)
+	for key, name := range map[string]string{
+		ctxutil.UserID: "id",
+		ctxutil.OrgID:  "org",
+		ctxutil.TeamID: "team",
+	} {
+		if v, ok := c.Get(key); ok && v != nil {
+			subject[name] = fmt.Sprint(v)
+		}
+	}
+	return permission.Attributes{
+		permission.AttrSubject: subject,
+		permission.AttrEnv: map[string]any{
+			"ip":   c.ClientIP(),
+			"time": time.Now(),
+		},
+	}
+}
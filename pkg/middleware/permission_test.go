@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/permission"
+)
+
+// testPolicies 测试使用的角色与策略。
+const testPolicies = `
+roles:
+  - name: order-owner
+    operations: ["org.*:orders:update"]
+    condition: resource.owner == @me
+  - name: user-admin
+    operations: ["sys:users:*"]
+policies:
+  - id: free-floating
+    statements:
+      - effect: allow
+        operations: ["sys:settings:update"]
+      - effect: allow
+        operations: ["sys:users:delete"]
+        resources: ["sys:users:42"]
+  - id: guardrails
+    statements:
+      - effect: deny
+        operations: ["sys:users:purge"]
+      - effect: deny
+        operations: ["sys:users:update"]
+        resources: ["sys:users:1"]
+      - effect: deny
+        operations: ["sys:users:export"]
+        condition: cidr(env.ip, "10.0.0.0/8")
+      - effect: deny
+        operations: ["sys:users:import"]
+        condition: resource.locked == true
+`
+
+func newTestStore(t *testing.T) *permission.PolicyStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	if err := os.WriteFile(path, []byte(testPolicies), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := permission.NewPolicyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestRequirePermissionWithStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := newTestStore(t)
+
+	ownedBy := func(owner string) func(c *gin.Context) (permission.Resource, map[string]any, error) {
+		return func(c *gin.Context) (permission.Resource, map[string]any, error) {
+			return "org.acme:orders:7", map[string]any{"owner": owner}, nil
+		}
+	}
+
+	tests := []struct {
+		name     string
+		op       string
+		userID   string
+		roles    []string // nil 表示未设置 ctxutil.Roles
+		grants   []string // nil 表示未设置 ctxutil.Permissions
+		resource func(c *gin.Context) (permission.Resource, map[string]any, error)
+		want     int
+	}{
+		{name: "unauthenticated", op: "sys:users:list", want: http.StatusUnauthorized},
+		{name: "role grants operation", op: "sys:users:list", userID: "1", roles: []string{"user-admin"}, want: http.StatusOK},
+		{name: "unknown role grants nothing", op: "sys:users:list", userID: "1", roles: []string{"ghost"}, want: http.StatusForbidden},
+
+		// 策略中的 allow 语句不绑定主体，不能授予权限
+		{name: "policy allow does not grant to user without roles", op: "sys:settings:update", userID: "2", roles: []string{}, want: http.StatusForbidden},
+		{name: "policy allow does not grant to user with unrelated grants", op: "sys:settings:update", userID: "2", grants: []string{"self:profile:*"}, want: http.StatusForbidden},
+		{name: "resource-scoped allow without Resource hook", op: "sys:users:delete", userID: "2", roles: []string{}, want: http.StatusForbidden},
+
+		// 带条件的角色
+		{name: "conditional role matches resource owner", op: "org.acme:orders:update", userID: "7", roles: []string{"order-owner"}, resource: ownedBy("7"), want: http.StatusOK},
+		{name: "conditional role other owner", op: "org.acme:orders:update", userID: "7", roles: []string{"order-owner"}, resource: ownedBy("8"), want: http.StatusForbidden},
+		{name: "conditional role without Resource hook", op: "org.acme:orders:update", userID: "7", roles: []string{"order-owner"}, want: http.StatusForbidden},
+
+		// deny 护栏
+		{name: "deny overrides role", op: "sys:users:purge", userID: "1", roles: []string{"user-admin"}, want: http.StatusForbidden},
+		{name: "deny overrides explicit grant", op: "sys:users:purge", userID: "1", grants: []string{"*:*:*"}, want: http.StatusForbidden},
+		{name: "resource-scoped deny without Resource hook", op: "sys:users:update", userID: "1", roles: []string{"user-admin"}, want: http.StatusOK},
+		{name: "resource-scoped deny with matching resource", op: "sys:users:update", userID: "1", roles: []string{"user-admin"},
+			resource: func(*gin.Context) (permission.Resource, map[string]any, error) { return "sys:users:1", nil, nil }, want: http.StatusForbidden},
+		{name: "conditional deny not matching", op: "sys:users:export", userID: "1", roles: []string{"user-admin"}, want: http.StatusOK},
+		{name: "conditional deny with missing attribute fails closed", op: "sys:users:import", userID: "1", roles: []string{"user-admin"}, want: http.StatusForbidden},
+		{name: "conditional deny with attribute false", op: "sys:users:import", userID: "1", roles: []string{"user-admin"},
+			resource: func(*gin.Context) (permission.Resource, map[string]any, error) {
+				return "", map[string]any{"locked": false}, nil
+			}, want: http.StatusOK},
+		{name: "resource hook error", op: "sys:users:list", userID: "1", roles: []string{"user-admin"},
+			resource: func(*gin.Context) (permission.Resource, map[string]any, error) { return "", nil, errors.New("db down") },
+			want:     http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set(ctxutil.UserID, tt.userID)
+				}
+				if tt.roles != nil {
+					c.Set(ctxutil.Roles, tt.roles)
+				}
+				if tt.grants != nil {
+					c.Set(ctxutil.Permissions, tt.grants)
+				}
+			})
+			r.GET("/", SetOperationID(tt.op), RequirePermissionWithConfig(PermissionConfig{
+				Store:    store,
+				Resource: tt.resource,
+			}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// RateLimitAlgorithm 限流算法。
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket 令牌桶：容量为 Burst（默认 Requests），每 Window 补充 Requests 个令牌，允许突发。
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow 滑动窗口计数：任意 Window 时长内最多 Requests 个请求（按前后两个固定窗口加权估算）。
+	SlidingWindow
+)
+
+// Limit 限流规则。
+type Limit struct {
+	Requests  int                // 每个窗口允许的请求数
+	Window    time.Duration      // 窗口长度
+	Burst     int                // 令牌桶容量，默认等于 Requests（SlidingWindow 忽略）
+	Algorithm RateLimitAlgorithm // 限流算法，默认 TokenBucket
+	Name      string             // 规则名称，作为计数键的前缀，使不同规则互不影响
+}
+
+// PerSecond 返回每秒 n 个请求的令牌桶规则。
+func PerSecond(n int) Limit { return Limit{Requests: n, Window: time.Second} }
+
+// PerMinute 返回每分钟 n 个请求的令牌桶规则。
+func PerMinute(n int) Limit { return Limit{Requests: n, Window: time.Minute} }
+
+// capacity 返回令牌桶容量。
+func (l Limit) capacity() int {
+	if l.Algorithm == TokenBucket && l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// policy 返回 RateLimit-Policy 头，如 100;w=60。
+func (l Limit) policy() string {
+	return strconv.Itoa(l.capacity()) + ";w=" + strconv.Itoa(int(math.Ceil(l.Window.Seconds())))
+}
+
+// LimitResult 一次限流判定的结果。
+type LimitResult struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 配额上限
+	Remaining  int           // 剩余配额
+	Reset      time.Duration // 配额恢复所需时间（令牌桶补满或当前窗口结束）
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
+
+// RateLimitStore 限流计数存储。
+//
+// 内置 [MemoryRateLimitStore]；多实例部署时可基于 Redis 等实现（如 Lua 脚本保证原子性）。
+// 实现必须是并发安全的。
+type RateLimitStore interface {
+	// Allow 消耗 key 的一个配额并返回判定结果。
+	Allow(ctx context.Context, key string, limit Limit) (LimitResult, error)
+}
+
+// KeyFunc 返回限流计数的键，返回空字符串表示不限流。
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP 按客户端 IP 限流。
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser 按 ctxutil.UserID 限流，未认证请求回退到客户端 IP。
+func KeyByUser(c *gin.Context) string {
+	if id, ok := c.Get(ctxutil.UserID); ok && id != nil {
+		return "user:" + fmt.Sprint(id)
+	}
+	return KeyByIP(c)
+}
+
+// KeyByOperation 按 Operation ID 限流（所有调用方共享配额）。
+//
+// 需位于 SetOperationID 之后，通常与 [KeyJoin] 组合使用。
+func KeyByOperation(c *gin.Context) string {
+	return "op:" + GetOperationID(c)
+}
+
+// KeyByHeader 按请求头（如 API Key）限流，请求头缺失时回退到客户端 IP。
+func KeyByHeader(name string) KeyFunc {
+	return func(c *gin.Context) string {
+		if v := c.GetHeader(name); v != "" {
+			return "hdr:" + v
+		}
+		return KeyByIP(c)
+	}
+}
+
+// KeyJoin 组合多个键，如按用户 + 操作限流：KeyJoin(KeyByUser, KeyByOperation)。
+func KeyJoin(fns ...KeyFunc) KeyFunc {
+	return func(c *gin.Context) string {
+		parts := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			k := fn(c)
+			if k == "" {
+				return ""
+			}
+			parts = append(parts, k)
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// RateLimitConfig 限流中间件配置。
+type RateLimitConfig struct {
+	// Limit 默认限流规则（[RateLimiter.Handler] 使用）。
+	Limit Limit
+
+	// Store 计数存储，默认使用新建的 [MemoryRateLimitStore]。
+	Store RateLimitStore
+
+	// Key 计数键，默认 [KeyByIP]。
+	Key KeyFunc
+
+	// Skip 跳过限流的请求（可选），如内网调用、健康检查。
+	Skip func(c *gin.Context) bool
+
+	// FailClosed 存储出错时返回 503。默认放行请求（fail open）并记录错误。
+	FailClosed bool
+}
+
+// RateLimiter 限流器，共享存储与计数键，可按不同规则生成中间件。
+type RateLimiter struct {
+	cfg RateLimitConfig
+}
+
+// NewRateLimiter 创建限流器。
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore(0)
+	}
+	if cfg.Key == nil {
+		cfg.Key = KeyByIP
+	}
+	return &RateLimiter{cfg: cfg}
+}
+
+// RateLimit 创建按客户端 IP 的内存限流中间件。
+//
+//	r.Use(middleware.RateLimit(middleware.PerSecond(20)))
+func RateLimit(limit Limit) gin.HandlerFunc {
+	return RateLimitWithConfig(RateLimitConfig{Limit: limit})
+}
+
+// RateLimitWithConfig 创建限流中间件。
+//
+// 详见 [RateLimiter.Limit]。
+func RateLimitWithConfig(cfg RateLimitConfig) gin.HandlerFunc {
+	return NewRateLimiter(cfg).Handler()
+}
+
+// Handler 返回使用默认规则的中间件。
+func (rl *RateLimiter) Handler() gin.HandlerFunc {
+	return rl.Limit(rl.cfg.Limit)
+}
+
+// Limit 返回使用指定规则的中间件，适合为单个路由设置更严格的限制。
+//
+// 每个请求都会设置响应头：
+//   - RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset（秒）
+//   - RateLimit-Policy，如 100;w=60
+//
+// 超出限制时返回 429（response.TooManyRequests）并设置 Retry-After（秒）。
+//
+// 示例：
+//
+//	limiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
+//	    Limit: middleware.PerSecond(50),
+//	    Key:   middleware.KeyByUser,
+//	})
+//	r.Use(limiter.Handler())
+//	r.POST("/login", limiter.Limit(middleware.Limit{Requests: 5, Window: time.Minute, Name: "login"}), login)
+func (rl *RateLimiter) Limit(limit Limit) gin.HandlerFunc {
+	if limit.Requests <= 0 || limit.Window <= 0 {
+		panic(fmt.Sprintf("middleware: invalid rate limit %+v", limit))
+	}
+	policy := limit.policy()
+
+	return func(c *gin.Context) {
+		if rl.cfg.Skip != nil && rl.cfg.Skip(c) {
+			c.Next()
+			return
+		}
+		key := rl.cfg.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if limit.Name != "" {
+			key = limit.Name + ":" + key
+		}
+
+		res, err := rl.cfg.Store.Allow(c, key, limit)
+		if err != nil {
+			_ = c.Error(fmt.Errorf("rate limit store: %w", err))
+			if rl.cfg.FailClosed {
+				response.ServiceUnavailable(c)
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(max(res.Remaining, 0)))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", policy)
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(max(ceilSeconds(res.RetryAfter), 1)))
+			response.TooManyRequests(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds 向上取整为秒。
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimitShards 内存存储的分片数。
+const rateLimitShards = 64
+
+// MemoryRateLimitStore 进程内限流存储，按键哈希分片以降低锁竞争。
+//
+// 计数在配额完全恢复后过期，由各分片在访问时惰性清理，无需后台 goroutine。
+// 仅适用于单实例部署，多实例需实现基于共享存储的 [RateLimitStore]。
+type MemoryRateLimitStore struct {
+	seed   maphash.Seed
+	sweep  time.Duration
+	shards [rateLimitShards]rateLimitShard
+}
+
+type rateLimitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+// rateLimitEntry 单个键的计数状态。
+type rateLimitEntry struct {
+	// 令牌桶
+	tokens float64
+	last   time.Time
+
+	// 滑动窗口
+	start time.Time // 当前固定窗口起点
+	curr  int       // 当前窗口计数
+	prev  int       // 上一窗口计数
+
+	expires time.Time
+}
+
+// NewMemoryRateLimitStore 创建内存存储，sweep 为过期清理间隔，≤0 时默认 1 分钟。
+func NewMemoryRateLimitStore(sweep time.Duration) *MemoryRateLimitStore {
+	if sweep <= 0 {
+		sweep = time.Minute
+	}
+	s := &MemoryRateLimitStore{seed: maphash.MakeSeed(), sweep: sweep}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*rateLimitEntry)
+	}
+	return s
+}
+
+// Len 返回当前计数的键数量（含尚未清理的过期键）。
+func (s *MemoryRateLimitStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		n += len(sh.entries)
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// Allow 实现 [RateLimitStore]。
+func (s *MemoryRateLimitStore) Allow(_ context.Context, key string, limit Limit) (LimitResult, error) {
+	now := time.Now()
+	sh := &s.shards[maphash.String(s.seed, key)%rateLimitShards]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if now.Sub(sh.lastSweep) >= s.sweep {
+		for k, e := range sh.entries {
+			if !now.Before(e.expires) {
+				delete(sh.entries, k)
+			}
+		}
+		sh.lastSweep = now
+	}
+
+	e, ok := sh.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &rateLimitEntry{}
+		sh.entries[key] = e
+	}
+
+	if limit.Algorithm == SlidingWindow {
+		return e.slidingWindow(now, limit), nil
+	}
+	return e.tokenBucket(now, limit), nil
+}
+
+// tokenBucket 令牌桶判定：按经过时间补充令牌，消耗一个令牌。
+func (e *rateLimitEntry) tokenBucket(now time.Time, limit Limit) LimitResult {
+	capacity := float64(limit.capacity())
+	rate := float64(limit.Requests) / float64(limit.Window) // 每纳秒补充的令牌数
+
+	if e.last.IsZero() {
+		e.tokens = capacity
+	} else if elapsed := now.Sub(e.last); elapsed > 0 {
+		e.tokens = math.Min(capacity, e.tokens+float64(elapsed)*rate)
+	}
+	e.last = now
+
+	res := LimitResult{Limit: int(capacity)}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - e.tokens) / rate))
+	}
+	res.Remaining = int(e.tokens)
+	res.Reset = time.Duration(math.Ceil((capacity - e.tokens) / rate))
+	e.expires = now.Add(res.Reset)
+	return res
+}
+
+// slidingWindow 滑动窗口计数判定：以上一窗口计数按剩余比例加权估算最近 Window 内的请求数。
+func (e *rateLimitEntry) slidingWindow(now time.Time, limit Limit) LimitResult {
+	window := limit.Window
+	start := now.Truncate(window)
+	switch {
+	case e.start.Equal(start):
+	case e.start.Add(window).Equal(start):
+		e.prev, e.curr = e.curr, 0
+	default:
+		e.prev, e.curr = 0, 0
+	}
+	e.start = start
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(window)
+	count := float64(e.prev)*weight + float64(e.curr)
+
+	res := LimitResult{Limit: limit.Requests, Reset: window - elapsed}
+	if count+1 <= float64(limit.Requests) {
+		e.curr++
+		count++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = e.retryAfter(elapsed, limit)
+	}
+	res.Remaining = limit.Requests - int(math.Ceil(count))
+	e.expires = start.Add(2 * window)
+	return res
+}
+
+// retryAfter 估算计数降到可以再放行一个请求所需的时间。
+func (e *rateLimitEntry) retryAfter(elapsed time.Duration, limit Limit) time.Duration {
+	window := limit.Window
+	if e.curr+1 > limit.Requests || e.prev == 0 {
+		return window - elapsed
+	}
+	// prev*(1-t/W) + curr + 1 <= N  =>  t >= W*(1-(N-curr-1)/prev)
+	need := time.Duration(float64(window) * (1 - float64(limit.Requests-e.curr-1)/float64(e.prev)))
+	return max(need-elapsed, time.Millisecond)
+}
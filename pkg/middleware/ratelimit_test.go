@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// t0 按分钟对齐，便于推算滑动窗口的边界。
+var t0 = time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+
+func TestTokenBucket(t *testing.T) {
+	limit := Limit{Requests: 10, Window: time.Second, Burst: 3}
+
+	type step struct {
+		at         time.Duration // 相对 t0
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration // 仅在拒绝时检查
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"burst then reject", []step{
+			{0, true, 2, 0},
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{0, false, 0, 100 * time.Millisecond},
+		}},
+		{"refill one token per interval", []step{
+			{0, true, 2, 0},
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{99 * time.Millisecond, false, 0, time.Millisecond},
+			{101 * time.Millisecond, true, 0, 0},
+			{102 * time.Millisecond, false, 0, 99 * time.Millisecond},
+		}},
+		{"refill capped at burst", []step{
+			{0, true, 2, 0},
+			{time.Hour, true, 2, 0},
+			{time.Hour, true, 1, 0},
+			{time.Hour, true, 0, 0},
+			{time.Hour, false, 0, 100 * time.Millisecond},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e rateLimitEntry
+			for i, s := range tt.steps {
+				res := e.tokenBucket(t0.Add(s.at), limit)
+				if res.Allowed != s.allowed || res.Remaining != s.remaining || res.Limit != 3 {
+					t.Fatalf("step %d: %+v, want allowed=%v remaining=%d", i, res, s.allowed, s.remaining)
+				}
+				if !s.allowed && (res.RetryAfter < s.retryAfter-time.Millisecond || res.RetryAfter > s.retryAfter+time.Millisecond) {
+					t.Fatalf("step %d: RetryAfter = %s, want ~%s", i, res.RetryAfter, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limit := Limit{Requests: 4, Window: time.Minute, Algorithm: SlidingWindow}
+
+	type step struct {
+		at         time.Duration // 相对 t0
+		n          int           // 连续请求次数
+		allowed    int           // 其中放行的次数
+		retryAfter time.Duration // 最后一次被拒绝时的 RetryAfter
+		reset      time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"limit within window", []step{
+			{0, 5, 4, time.Minute, time.Minute},
+		}},
+		{"previous window fully weighted at boundary", []step{
+			{0, 4, 4, 0, time.Minute},
+			{time.Minute, 1, 0, 15 * time.Second, time.Minute},
+		}},
+		{"previous window decays", []step{
+			{0, 4, 4, 0, time.Minute},
+			{time.Minute + 15*time.Second, 2, 1, 15 * time.Second, 45 * time.Second},
+			{time.Minute + 45*time.Second, 3, 2, 15 * time.Second, 15 * time.Second},
+		}},
+		{"idle for two windows resets", []step{
+			{0, 4, 4, 0, time.Minute},
+			{2*time.Minute + 30*time.Second, 5, 4, 30 * time.Second, 30 * time.Second},
+		}},
+		{"current window full", []step{
+			{30 * time.Second, 4, 4, 0, 30 * time.Second},
+			{59 * time.Second, 1, 0, time.Second, time.Second},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e rateLimitEntry
+			for i, s := range tt.steps {
+				allowed := 0
+				var res LimitResult
+				for range s.n {
+					res = e.slidingWindow(t0.Add(s.at), limit)
+					if res.Allowed {
+						allowed++
+					}
+				}
+				if allowed != s.allowed {
+					t.Fatalf("step %d: allowed %d of %d, want %d", i, allowed, s.n, s.allowed)
+				}
+				if res.Reset != s.reset {
+					t.Fatalf("step %d: Reset = %s, want %s", i, res.Reset, s.reset)
+				}
+				if !res.Allowed && res.RetryAfter != s.retryAfter {
+					t.Fatalf("step %d: RetryAfter = %s, want %s", i, res.RetryAfter, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreKeys(t *testing.T) {
+	store := NewMemoryRateLimitStore(0)
+	limit := PerMinute(1)
+
+	tests := []struct {
+		key     string
+		allowed bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"a", false},
+	}
+	for i, tt := range tests {
+		res, err := store.Allow(context.Background(), tt.key, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != tt.allowed {
+			t.Fatalf("request %d (%q): allowed = %v, want %v", i, tt.key, res.Allowed, tt.allowed)
+		}
+	}
+	if store.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", store.Len())
+	}
+}
+
+// failingStore 总是返回错误的存储。
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, Limit) (LimitResult, error) {
+	return LimitResult{}, errors.New("redis down")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		cfg   RateLimitConfig
+		codes []int
+	}{
+		{"rejects over limit", RateLimitConfig{Limit: PerMinute(2)}, []int{200, 200, 429}},
+		{"skip", RateLimitConfig{Limit: PerMinute(1), Skip: func(*gin.Context) bool { return true }}, []int{200, 200}},
+		{"empty key is not limited", RateLimitConfig{Limit: PerMinute(1), Key: func(*gin.Context) string { return "" }}, []int{200, 200}},
+		{"store error fails open", RateLimitConfig{Limit: PerMinute(1), Store: failingStore{}}, []int{200, 200}},
+		{"store error fails closed", RateLimitConfig{Limit: PerMinute(1), Store: failingStore{}, FailClosed: true}, []int{503}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RateLimitWithConfig(tt.cfg))
+			r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, want := range tt.codes {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				if w.Code != want {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, want)
+				}
+				if w.Code == http.StatusTooManyRequests {
+					if ra, _ := strconv.Atoi(w.Header().Get("Retry-After")); ra < 1 {
+						t.Fatalf("Retry-After = %q", w.Header().Get("Retry-After"))
+					}
+					if w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Policy") != "2;w=60" {
+						t.Fatalf("headers = %v", w.Header())
+					}
+				}
+			}
+		})
+	}
+}
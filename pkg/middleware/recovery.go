@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// RecoveryConfig panic 恢复中间件配置。
+type RecoveryConfig struct {
+	// Logger 记录 panic 的 logger，默认使用 [LoggerFrom]（携带 request_id 等请求属性）。
+	Logger *slog.Logger
+
+	// OnPanic 上报钩子（可选），在记录日志之后、写入响应之前调用，
+	// 用于将 panic 上报到 Sentry 等错误追踪服务。连接断开引起的 panic 不会触发。
+	OnPanic func(c *gin.Context, recovered any, stack []byte)
+
+	// ExposeStack 在 500 响应的 error 字段附带 panic 信息与调用栈。
+	// 仅在 gin debug 模式下生效，生产环境（release 模式）自动忽略。
+	ExposeStack bool
+}
+
+// Recovery 创建 panic 恢复中间件（默认配置）。
+//
+// 详见 [RecoveryWithConfig]。
+func Recovery() gin.HandlerFunc {
+	return RecoveryWithConfig(RecoveryConfig{})
+}
+
+// RecoveryWithConfig 创建 panic 恢复中间件，替代 gin.Recovery()。
+//
+// 处理流程：
+//   - 客户端连接已断开（broken pipe、connection reset、http.ErrAbortHandler）：
+//     记录 WARN 日志并中止，不写入响应
+//   - 其他 panic：以 ERROR 级别记录 panic 值与调用栈，调用 OnPanic，
+//     返回统一格式的 response.InternalError
+//   - 响应已开始写入时无法再返回错误，只中止处理链
+//
+// 应作为第一个中间件注册（RequestID 之后），以覆盖其他中间件中的 panic：
+//
+//	r.Use(middleware.RequestID(), middleware.RecoveryWithConfig(middleware.RecoveryConfig{
+//	    OnPanic: func(c *gin.Context, rec any, stack []byte) { sentry.CurrentHub().Recover(rec) },
+//	}))
+func RecoveryWithConfig(cfg RecoveryConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			logger := cfg.Logger
+			if logger == nil {
+				logger = LoggerFrom(c)
+			} else if id := GetRequestID(c); id != "" {
+				logger = logger.With("request_id", id)
+			}
+			logger = logger.With("method", c.Request.Method, "path", c.Request.URL.Path)
+
+			// Timeout 在独立 goroutine 中执行处理链，使用其捕获的调用栈定位 panic 位置
+			stack := debug.Stack()
+			if p, ok := rec.(*handlerPanic); ok {
+				rec, stack = p.value, p.stack
+			}
+
+			if brokenConnection(rec) {
+				logger.WarnContext(c, "connection broken", "error", fmt.Sprint(rec))
+				_ = c.Error(fmt.Errorf("connection broken: %v", rec))
+				c.Abort()
+				return
+			}
+
+			logger.ErrorContext(c, "panic recovered", "panic", fmt.Sprint(rec), "stack", string(stack))
+			_ = c.Error(fmt.Errorf("panic: %v", rec))
+
+			if cfg.OnPanic != nil {
+				cfg.OnPanic(c, rec, stack)
+			}
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			if cfg.ExposeStack && gin.IsDebugging() {
+				response.InternalError(c, gin.H{
+					"panic": fmt.Sprint(rec),
+					"stack": strings.Split(strings.TrimSpace(string(stack)), "\n"),
+				})
+			} else {
+				response.InternalError(c)
+			}
+			c.Abort()
+		}()
+
+		c.Next()
+	}
+}
+
+// brokenConnection 报告 panic 是否由客户端断开连接引起，此时写入响应没有意义。
+func brokenConnection(rec any) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(err, http.ErrAbortHandler) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var ne *net.OpError
+	if errors.As(err, &ne) {
+		var se *os.SyscallError
+		if errors.As(ne, &se) {
+			msg := strings.ToLower(se.Error())
+			return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+		}
+	}
+	return false
+}
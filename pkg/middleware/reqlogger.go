@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+)
+
+// RequestLoggerKey 是请求级 logger 在 Gin context 中的键名。
+const RequestLoggerKey = "request_logger"
+
+// RequestLoggerConfig 请求级 logger 中间件配置。
+type RequestLoggerConfig struct {
+	// Logger 基础 logger，默认 slog.Default()。
+	Logger *slog.Logger
+
+	// Attrs 返回附加的请求属性（可选），如租户、客户端版本等。
+	Attrs func(c *gin.Context) []slog.Attr
+}
+
+// RequestLogger 创建请求级 logger 中间件（默认配置）。
+//
+// 详见 [RequestLoggerWithConfig]。
+func RequestLogger() gin.HandlerFunc {
+	return RequestLoggerWithConfig(RequestLoggerConfig{})
+}
+
+// RequestLoggerWithConfig 创建请求级 logger 中间件。
+//
+// 为每个请求创建携带 request_id、operation、user_id、org_id 的子 logger，
+// 存入 Gin context（[LoggerFrom]）与 c.Request.Context()（[LoggerFromContext]），
+// 处理器与下游的 service 代码输出的日志都能关联到请求。
+//
+// 必须放在 [RequestID] 之后；放在认证中间件与 [SetOperationID] 之后时，
+// 标准 context 中的 logger 才包含 user_id、org_id、operation
+// （[LoggerFrom] 在调用时读取，不受顺序影响）。
+//
+// 示例：
+//
+//	r.Use(middleware.RequestID(), middleware.RequestLogger())
+//
+//	func getUser(c *gin.Context) {
+//	    middleware.LoggerFrom(c).Info("loading user", "id", c.Param("id"))
+//	}
+func RequestLoggerWithConfig(cfg RequestLoggerConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base := cfg.Logger
+		if base == nil {
+			base = slog.Default()
+		}
+		if cfg.Attrs != nil {
+			if attrs := cfg.Attrs(c); len(attrs) > 0 {
+				base = slog.New(base.Handler().WithAttrs(attrs))
+			}
+		}
+
+		fields := ginFields(c)
+		c.Set(RequestLoggerKey, &requestLogger{base: base})
+		ctx := context.WithValue(c.Request.Context(), logFieldsKey{}, fields)
+		ctx = ContextWithLogger(ctx, withFields(base, fields))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// LoggerFrom 返回当前请求的 logger。
+//
+// 属性在调用时从 Gin context 读取，因此认证等后续中间件设置的 user_id 也会包含在内。
+// 未使用 [RequestLogger] 时返回携带相同属性的 slog.Default()。
+func LoggerFrom(c *gin.Context) *slog.Logger {
+	rl, ok := ctxutil.Get[*requestLogger](c, RequestLoggerKey)
+	if !ok {
+		return withFields(slog.Default(), ginFields(c))
+	}
+	return rl.get(ginFields(c))
+}
+
+// LoggerFromContext 从标准 context 获取 logger，供非 Gin 代码使用。
+//
+// ctx 为 *gin.Context 时等同于 [LoggerFrom]；不存在时返回 slog.Default()。
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if c, ok := ctx.(*gin.Context); ok {
+		return LoggerFrom(c)
+	}
+	if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// ContextWithLogger 返回携带 logger 的 context。
+func ContextWithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
+
+type (
+	loggerContextKey struct{}
+	logFieldsKey     struct{}
+)
+
+// requestLogger 缓存请求级 logger，请求属性变化时才重新构造。
+//
+// 处理函数可能在 [Timeout] 的 goroutine 中调用 [LoggerFrom]，缓存由 mu 保护。
+type requestLogger struct {
+	base *slog.Logger
+
+	mu     sync.Mutex
+	fields logFields
+	cached *slog.Logger
+}
+
+func (rl *requestLogger) get(f logFields) *slog.Logger {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.cached == nil || f != rl.fields {
+		rl.fields = f
+		rl.cached = withFields(rl.base, f)
+	}
+	return rl.cached
+}
+
+// logFields 请求关联的日志属性。
+type logFields struct {
+	requestID string
+	operation string
+	userID    any
+	orgID     any
+}
+
+// attrs 返回非空属性。
+func (f logFields) attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, 4)
+	if f.requestID != "" {
+		attrs = append(attrs, slog.String("request_id", f.requestID))
+	}
+	if f.operation != "" {
+		attrs = append(attrs, slog.String("operation", f.operation))
+	}
+	if f.userID != nil {
+		attrs = append(attrs, slog.Any("user_id", f.userID))
+	}
+	if f.orgID != nil {
+		attrs = append(attrs, slog.Any("org_id", f.orgID))
+	}
+	return attrs
+}
+
+// ginFields 从 Gin context 读取日志属性。
+func ginFields(c *gin.Context) logFields {
+	userID, _ := c.Get(ctxutil.UserID)
+	orgID, _ := c.Get(ctxutil.OrgID)
+	return logFields{
+		requestID: GetRequestID(c),
+		operation: GetOperationID(c),
+		userID:    loggable(userID),
+		orgID:     loggable(orgID),
+	}
+}
+
+// loggable 将常见 ID 类型以外的值转换为字符串（logFields 需要可比较以判断是否变化）。
+//
+// 不能只检查类型是否可比较：含接口字段的结构体类型可比较，但字段中的切片等值在比较时仍会 panic。
+func loggable(v any) any {
+	switch v.(type) {
+	case nil, string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// withFields 返回附加了请求属性的 logger。
+func withFields(l *slog.Logger, f logFields) *slog.Logger {
+	attrs := f.attrs()
+	if len(attrs) == 0 {
+		return l
+	}
+	return slog.New(l.Handler().WithAttrs(attrs))
+}
+
+// ============================================================================
+// slog.Handler 包装
+// ============================================================================
+
+// ContextHandler 从 context 中自动提取请求属性的 slog.Handler。
+//
+// 使用 slog.InfoContext(ctx, ...) 等带 context 的方法输出日志时，
+// 自动附加 request_id、operation、user_id、org_id，无需传递请求级 logger：
+//
+//	slog.SetDefault(slog.New(middleware.NewContextHandler(slog.NewJSONHandler(os.Stdout, nil))))
+//
+//	func (s *UserService) Create(ctx context.Context, ...) {
+//	    slog.InfoContext(ctx, "user created") // 自动包含 request_id 等
+//	}
+//
+// 不要与 [LoggerFrom] 返回的 logger 叠加使用，否则属性会重复。
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler 包装 h。
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+// Handle 附加 context 中的请求属性后交给被包装的 Handler。
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(contextFields(ctx).attrs()...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs 实现 slog.Handler。
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 实现 slog.Handler。
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// contextFields 从 context 读取日志属性。
+func contextFields(ctx context.Context) logFields {
+	if c, ok := ctx.(*gin.Context); ok {
+		return ginFields(c)
+	}
+	if f, ok := ctx.Value(logFieldsKey{}).(logFields); ok {
+		return f
+	}
+	return logFields{requestID: RequestIDFromContext(ctx)}
+}
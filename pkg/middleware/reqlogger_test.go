@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+)
+
+// principal 类型可比较，但 Scopes 中的切片在比较时会 panic。
+type principal struct {
+	ID     string
+	Scopes any
+}
+
+func TestLoggable(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want any
+	}{
+		{"nil", nil, nil},
+		{"string", "7", "7"},
+		{"int64", int64(7), int64(7)},
+		{"uint", uint(7), uint(7)},
+		{"slice", []string{"a"}, "[a]"},
+		{"map", map[string]int{"a": 1}, "map[a:1]"},
+		{"struct with uncomparable interface field", principal{ID: "7", Scopes: []string{"a"}}, "{7 [a]}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := loggable(tt.v)
+			if got != tt.want {
+				t.Fatalf("loggable = %#v, want %#v", got, tt.want)
+			}
+			// logFields 之间的比较不能 panic
+			_ = logFields{userID: got} == logFields{userID: got}
+		})
+	}
+}
+
+func TestLoggerFromConcurrent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	base := slog.New(slog.NewTextHandler(&lockedWriter{w: &buf, mu: &mu}, nil))
+
+	r := gin.New()
+	r.Use(RequestLoggerWithConfig(RequestLoggerConfig{Logger: base}), Timeout(time.Second))
+	r.GET("/", func(c *gin.Context) {
+		c.Set(ctxutil.UserID, principal{ID: "7", Scopes: []string{"a"}})
+
+		// 处理函数在 Timeout 的 goroutine 中执行，再并发调用 LoggerFrom
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() { LoggerFrom(c).Info("hello") })
+		}
+		wg.Wait()
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if n := strings.Count(buf.String(), `user_id="{7 [a]}"`); n != 8 {
+		t.Fatalf("got %d log lines with user_id, want 8:\n%s", n, buf.String())
+	}
+}
+
+// lockedWriter 串行化并发写入。
+type lockedWriter struct {
+	w  *bytes.Buffer
+	mu *sync.Mutex
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
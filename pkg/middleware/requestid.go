@@ -1,6 +1,13 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
@@ -12,32 +19,108 @@ const RequestIDKey = "request_id"
 // RequestIDHeader 是 Request ID 响应头名称。
 const RequestIDHeader = "X-Request-ID"
 
-// RequestID 创建 Request ID 中间件。
+// TraceParentHeader 是 W3C Trace Context 请求头名称。
+const TraceParentHeader = "traceparent"
+
+// DefaultRequestIDMaxLength 入站 Request ID 的默认最大长度。
+const DefaultRequestIDMaxLength = 128
+
+// RequestIDConfig Request ID 中间件配置。
+type RequestIDConfig struct {
+	// TrustHeader 信任的入站请求头，如 X-Request-ID。
+	// 为空时不信任入站 ID（默认），仅在请求来自可信网关时设置。
+	TrustHeader string
+
+	// MaxLength 入站 ID 的最大长度，默认 128。
+	MaxLength int
+
+	// Validate 校验入站 ID，默认只允许字母、数字与 - _ . :。
+	// 校验失败的入站 ID 会被忽略并重新生成。
+	Validate func(id string) bool
+
+	// TraceParent 无 OTel Span 时解析 W3C traceparent 请求头，使用其 Trace ID。
+	TraceParent bool
+
+	// Generator 生成新 ID，默认 [NewUUIDv4]，可选 [NewUUIDv7]、[NewULID]。
+	Generator func() string
+
+	// Header 响应头名称，默认 X-Request-ID。
+	Header string
+}
+
+// RequestID 创建 Request ID 中间件（默认配置）。
 //
 // 优先从 OpenTelemetry Span 提取 Trace ID 作为 Request ID，
 // 如果 OTel 未启用，则生成 UUID 作为 fallback。
 //
 // Request ID 会被：
 //   - 存入 Gin context（供后续中间件和处理器使用）
+//   - 存入 c.Request.Context()（供非 Gin 代码通过 [RequestIDFromContext] 读取）
 //   - 设置到 X-Request-ID 响应头（供客户端追踪）
 func RequestID() gin.HandlerFunc {
+	return RequestIDWithConfig(RequestIDConfig{})
+}
+
+// RequestIDWithConfig 创建 Request ID 中间件。
+//
+// Request ID 的来源优先级：
+//  1. TrustHeader 指定的入站请求头（通过长度与字符校验）
+//  2. OTel Span 的 Trace ID
+//  3. traceparent 请求头的 Trace ID（TraceParent 为 true 时）
+//  4. Generator 生成
+//
+// 示例（网关已生成 X-Request-ID）：
+//
+//	r.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+//	    TrustHeader: middleware.RequestIDHeader,
+//	    TraceParent: true,
+//	    Generator:   middleware.NewUUIDv7,
+//	}))
+func RequestIDWithConfig(cfg RequestIDConfig) gin.HandlerFunc {
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = DefaultRequestIDMaxLength
+	}
+	if cfg.Validate == nil {
+		cfg.Validate = validRequestID
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = NewUUIDv4
+	}
+	if cfg.Header == "" {
+		cfg.Header = RequestIDHeader
+	}
+
 	return func(c *gin.Context) {
 		var requestID string
 
-		// 优先从 OTel Span 获取 Trace ID
-		span := trace.SpanFromContext(c.Request.Context())
-		if span.SpanContext().IsValid() {
-			requestID = span.SpanContext().TraceID().String()
-		} else {
-			// Fallback: 生成 UUID
-			requestID = uuid.New().String()
+		// 可信网关传入的 ID
+		if cfg.TrustHeader != "" {
+			if id := c.GetHeader(cfg.TrustHeader); id != "" && len(id) <= cfg.MaxLength && cfg.Validate(id) {
+				requestID = id
+			}
+		}
+
+		// 从 OTel Span 或 traceparent 获取 Trace ID
+		if requestID == "" {
+			span := trace.SpanFromContext(c.Request.Context())
+			if span.SpanContext().IsValid() {
+				requestID = span.SpanContext().TraceID().String()
+			} else if cfg.TraceParent {
+				requestID, _ = parseTraceParent(c.GetHeader(TraceParentHeader))
+			}
 		}
 
-		// 存入 Gin context
+		// Fallback: 生成新 ID
+		if requestID == "" {
+			requestID = cfg.Generator()
+		}
+
+		// 存入 Gin context 与标准 context
 		c.Set(RequestIDKey, requestID)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), requestID))
 
 		// 设置响应头（便于客户端追踪）
-		c.Header(RequestIDHeader, requestID)
+		c.Header(cfg.Header, requestID)
 
 		c.Next()
 	}
@@ -53,3 +136,105 @@ func GetRequestID(c *gin.Context) string {
 	}
 	return ""
 }
+
+// ============================================================================
+// 标准 context 传递
+// ============================================================================
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID 返回携带 Request ID 的 context。
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext 从标准 context 获取 Request ID，供 service、repository 等非 Gin 代码使用。
+// 如果不存在返回空字符串。
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// ============================================================================
+// ID 生成与解析
+// ============================================================================
+
+// NewUUIDv4 生成随机 UUID（v4）。
+func NewUUIDv4() string {
+	return uuid.NewString()
+}
+
+// NewUUIDv7 生成按时间排序的 UUID（v7），适合作为数据库索引。
+func NewUUIDv7() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// crockford ULID 使用的 Crockford Base32 字母表。
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID 生成 ULID：48 位毫秒时间戳 + 80 位随机数，26 个字符，按时间排序。
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(b[6:])
+
+	// 128 位按 5 位一组编码，首字符只有 3 位有效
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// validRequestID 默认的入站 ID 校验：只允许字母、数字与 - _ . :。
+func validRequestID(id string) bool {
+	for i := range len(id) {
+		ch := id[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':':
+		default:
+			return false
+		}
+	}
+	return id != ""
+}
+
+// parseTraceParent 解析 W3C traceparent（version-traceid-parentid-flags），返回 Trace ID。
+//
+//	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func parseTraceParent(s string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", false
+	}
+	// 版本 00 必须恰好 4 段；ff 为无效版本
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", false
+	}
+	for _, p := range parts[:4] {
+		if !isLowerHex(p) {
+			return "", false
+		}
+	}
+	if parts[1] == strings.Repeat("0", 32) || parts[2] == strings.Repeat("0", 16) {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// isLowerHex 报告字符串是否只包含小写十六进制字符。
+func isLowerHex(s string) bool {
+	if _, err := hex.DecodeString(s); err != nil {
+		return false
+	}
+	return strings.ToLower(s) == s
+}
@@ -0,0 +1,412 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/permission"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// TimeoutConfig 请求超时中间件配置。
+type TimeoutConfig struct {
+	// Timeout 默认超时时间，≤0 表示不限制（仍可由 Operations 或 [RouteTimeout] 设置）。
+	Timeout time.Duration
+
+	// Operations 按 Operation 模式设置超时，在 SetOperationID 执行时按顺序匹配第一个，
+	// 模式语法同 permission.MatchOperation，如 sys:reports:*。
+	Operations []OperationTimeout
+
+	// Status 超时响应状态码，默认 504（response.GatewayTimeout），
+	// 设为 503 时使用 response.ServiceUnavailable。
+	Status int
+
+	// Message 超时响应消息，为空时使用状态码的默认消息。
+	Message string
+
+	// Skip 跳过超时控制的请求（可选），如 WebSocket 等需要 Hijack 的长连接。
+	Skip func(c *gin.Context) bool
+}
+
+// OperationTimeout 匹配 Operation 模式的超时时间。
+type OperationTimeout struct {
+	Pattern string
+	Timeout time.Duration // ≤0 表示不限制
+}
+
+// timeoutKey 是超时状态在 Gin context 中的键名。
+const timeoutKey = "timeout"
+
+// Timeout 创建请求超时中间件（超时返回 504）。
+//
+// 详见 [TimeoutWithConfig]。
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: d})
+}
+
+// TimeoutWithConfig 创建请求超时中间件。
+//
+// 处理流程：
+//   - 为 c.Request.Context() 设置截止时间，后续处理链在独立的 goroutine 中执行，
+//     输出先写入缓冲区，正常完成后再写入响应
+//   - 超时后立即返回统一格式的 504（或 503）响应，此后处理函数的写入被丢弃并返回 http.ErrHandlerTimeout，
+//     中间件仍等待处理链结束后才返回，保证 Gin context 不会在使用中被回收
+//   - 处理函数调用 Flush（SSE 等流式响应）后改为直接写入，超时时只取消 context
+//   - 处理链中的 panic 连同其调用栈在当前 goroutine 重新抛出，交由 [Recovery] 处理
+//
+// 超时时间的优先级：[RouteTimeout] > Operations > Timeout，均从请求进入本中间件时起算。
+//
+// 处理函数必须通过 c.Request.Context()（或启用 engine.ContextWithFallback 后的 c）
+// 感知超时并尽快返回，否则只能等待其自然结束。
+// http.Server 的 WriteTimeout 应大于最长的超时时间，否则连接会在返回响应前被关闭。
+//
+// 示例：
+//
+//	r.Use(middleware.RequestID(), middleware.Recovery(), middleware.TimeoutWithConfig(middleware.TimeoutConfig{
+//	    Timeout: 5 * time.Second,
+//	    Operations: []middleware.OperationTimeout{
+//	        {Pattern: "sys:reports:*", Timeout: time.Minute},
+//	    },
+//	}))
+func TimeoutWithConfig(cfg TimeoutConfig) gin.HandlerFunc {
+	if cfg.Status == 0 {
+		cfg.Status = http.StatusGatewayTimeout
+	}
+	return func(c *gin.Context) {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			c.Next()
+			return
+		}
+		runWithTimeout(c, &cfg, cfg.Timeout)
+	}
+}
+
+// RouteTimeout 为当前路由设置超时时间，≤0 表示不限制。
+//
+// 位于 [TimeoutWithConfig] 之后时只调整截止时间（可长于默认值），否则等同于 [Timeout]。
+// 声明式路由设置 routes.Route.Timeout 即可自动添加。
+func RouteTimeout(d time.Duration) gin.HandlerFunc {
+	cfg := &TimeoutConfig{Timeout: d, Status: http.StatusGatewayTimeout}
+	return func(c *gin.Context) {
+		if st, ok := ctxutil.Get[*timeoutState](c, timeoutKey); ok {
+			st.set(c, d)
+			c.Next()
+			return
+		}
+		runWithTimeout(c, cfg, d)
+	}
+}
+
+// applyOperationTimeout 按 Operation 模式调整超时时间，由 SetOperationID 调用。
+func applyOperationTimeout(c *gin.Context, operationID string) {
+	st, ok := ctxutil.Get[*timeoutState](c, timeoutKey)
+	if !ok {
+		return
+	}
+	for _, ot := range st.cfg.Operations {
+		if permission.MatchOperation(ot.Pattern, operationID) {
+			st.set(c, ot.Timeout)
+			return
+		}
+	}
+}
+
+// timeoutState 单个请求的超时状态。
+type timeoutState struct {
+	cfg     *TimeoutConfig
+	start   time.Time
+	parent  context.Context      // 进入中间件时的请求 context（不含截止时间）
+	updates chan context.Context // 处理链中调整截止时间后的 context
+	cancels []func()             // 仅由处理链 goroutine 追加，结束后由中间件调用
+}
+
+// set 以 d 重新设置截止时间，保留 context 中的值与客户端断开引起的取消。
+func (st *timeoutState) set(c *gin.Context, d time.Duration) {
+	base := context.WithoutCancel(c.Request.Context())
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if d > 0 {
+		ctx, cancel = context.WithDeadline(base, st.start.Add(d))
+	} else {
+		ctx, cancel = context.WithCancel(base)
+	}
+	stop := context.AfterFunc(st.parent, cancel)
+	st.cancels = append(st.cancels, func() { stop(); cancel() })
+	c.Request = c.Request.WithContext(ctx)
+
+	// 只有处理链 goroutine 发送，丢弃尚未被读取的旧值后一定能写入
+	select {
+	case <-st.updates:
+	default:
+	}
+	st.updates <- ctx
+}
+
+// runWithTimeout 在独立 goroutine 中执行后续处理链，d 为初始超时时间。
+func runWithTimeout(c *gin.Context, cfg *TimeoutConfig, d time.Duration) {
+	st := &timeoutState{
+		cfg:     cfg,
+		start:   time.Now(),
+		parent:  c.Request.Context(),
+		updates: make(chan context.Context, 1),
+	}
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if d > 0 {
+		ctx, cancel = context.WithDeadline(st.parent, st.start.Add(d))
+	} else {
+		ctx, cancel = context.WithCancel(st.parent)
+	}
+	defer cancel()
+
+	w := c.Writer
+	tw := &timeoutWriter{ResponseWriter: w, header: w.Header().Clone(), status: w.Status()}
+	c.Writer = tw
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(timeoutKey, st)
+
+	done := make(chan struct{})
+	var panicked *handlerPanic
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				panicked = &handlerPanic{value: rec, stack: debug.Stack()}
+			}
+			close(done)
+		}()
+		c.Next()
+	}()
+
+	timedOut := false
+	watch := ctx
+wait:
+	for {
+		select {
+		case <-done:
+			break wait
+		case watch = <-st.updates:
+		case <-watch.Done():
+			// 截止时间已被调整，改为等待新的 context
+			select {
+			case watch = <-st.updates:
+				continue
+			default:
+			}
+			if errors.Is(watch.Err(), context.DeadlineExceeded) {
+				timedOut = true
+				tw.timeout(cfg)
+			}
+			<-done
+			break wait
+		}
+	}
+
+	for _, fn := range st.cancels {
+		fn()
+	}
+	c.Writer = w
+	if timedOut {
+		_ = c.Error(fmt.Errorf("request timeout after %s: %w", time.Since(st.start).Round(time.Millisecond), context.DeadlineExceeded))
+	}
+	if panicked != nil {
+		// http.ErrAbortHandler 原样抛出，由 net/http 静默中止连接
+		if panicked.value == http.ErrAbortHandler {
+			panic(panicked.value)
+		}
+		panic(panicked)
+	}
+	if timedOut {
+		c.Abort()
+		return
+	}
+	tw.commit()
+}
+
+// handlerPanic 处理链 goroutine 中的 panic，携带原始调用栈，由 [Recovery] 解包记录。
+type handlerPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *handlerPanic) Error() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+// Unwrap 返回原始 panic 值（为 error 时），使 errors.Is 等判断仍然有效。
+func (p *handlerPanic) Unwrap() error {
+	err, _ := p.value.(error)
+	return err
+}
+
+// ============================================================================
+// 缓冲写入器
+// ============================================================================
+
+// timeoutWriter 缓冲处理链的输出，使超时后的迟到写入不会与超时响应竞争。
+type timeoutWriter struct {
+	gin.ResponseWriter // 原始写入器
+
+	mu        sync.Mutex
+	header    http.Header
+	buf       bytes.Buffer
+	status    int
+	written   bool
+	streaming bool // 已 Flush，直接写入原始写入器
+	timedOut  bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+	case w.streaming:
+		w.ResponseWriter.WriteHeader(code)
+	case !w.written:
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+	case w.streaming:
+		w.ResponseWriter.WriteHeaderNow()
+	default:
+		w.written = true
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.timedOut:
+		return 0, http.ErrHandlerTimeout
+	case w.streaming:
+		return w.ResponseWriter.Write(b)
+	}
+	w.written = true
+	return w.buf.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.streaming {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case w.streaming:
+		return w.ResponseWriter.Size()
+	case !w.written:
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written || w.streaming
+}
+
+// Flush 写出已缓冲的内容并切换为直接写入。
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return
+	}
+	if !w.streaming {
+		w.streaming = true
+		w.writeBuffered()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack 超时控制下不支持接管连接，WebSocket 等路由应通过 TimeoutConfig.Skip 跳过。
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("middleware: Hijack is not supported under Timeout")
+}
+
+func (w *timeoutWriter) Pusher() http.Pusher {
+	return nil
+}
+
+// timeout 写入超时响应，此后处理链的写入被丢弃。已开始流式输出时只能中止写入。
+func (w *timeoutWriter) timeout(cfg *TimeoutConfig) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timedOut = true
+	if w.streaming {
+		return
+	}
+
+	// 处理链仍在另一个 goroutine 中使用 c，使用独立的 Context 写入超时响应
+	tc := &gin.Context{Writer: w.ResponseWriter}
+	switch cfg.Status {
+	case http.StatusGatewayTimeout:
+		response.GatewayTimeout(tc, cfg.Message)
+	case http.StatusServiceUnavailable:
+		response.ServiceUnavailable(tc, cfg.Message)
+	default:
+		response.Failure(tc, cfg.Status, cmp.Or(cfg.Message, http.StatusText(cfg.Status)))
+	}
+	w.ResponseWriter.Flush()
+}
+
+// commit 处理链正常结束后写出缓冲的响应。
+func (w *timeoutWriter) commit() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.streaming {
+		w.writeBuffered()
+	}
+}
+
+// writeBuffered 将缓冲的响应头与响应体写入原始写入器。调用方需持有 w.mu。
+func (w *timeoutWriter) writeBuffered() {
+	dst := w.ResponseWriter.Header()
+	clear(dst)
+	maps.Copy(dst, w.header)
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf = bytes.Buffer{}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// lateWrite 在超时响应写出之后再写入，记录写入结果。
+	// context 取消与超时响应之间没有先后保证，因此额外等待一段时间。
+	lateErr := make(chan error, 1)
+	lateWrite := func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		time.Sleep(50 * time.Millisecond)
+		c.Header("X-Late", "1")
+		_, err := c.Writer.WriteString("late")
+		lateErr <- err
+	}
+
+	tests := []struct {
+		name     string
+		cfg      TimeoutConfig
+		handlers []gin.HandlerFunc
+		want     int
+		wantBody string
+	}{
+		{
+			name:     "completes in time",
+			cfg:      TimeoutConfig{Timeout: time.Second},
+			handlers: []gin.HandlerFunc{func(c *gin.Context) { c.String(http.StatusCreated, "ok") }},
+			want:     http.StatusCreated,
+			wantBody: "ok",
+		},
+		{
+			name:     "late write is discarded",
+			cfg:      TimeoutConfig{Timeout: 20 * time.Millisecond},
+			handlers: []gin.HandlerFunc{lateWrite},
+			want:     http.StatusGatewayTimeout,
+		},
+		{
+			name:     "custom status",
+			cfg:      TimeoutConfig{Timeout: 20 * time.Millisecond, Status: http.StatusServiceUnavailable, Message: "busy"},
+			handlers: []gin.HandlerFunc{lateWrite},
+			want:     http.StatusServiceUnavailable,
+			wantBody: "busy",
+		},
+		{
+			name: "route timeout extends deadline",
+			cfg:  TimeoutConfig{Timeout: 20 * time.Millisecond},
+			handlers: []gin.HandlerFunc{RouteTimeout(time.Second), func(c *gin.Context) {
+				time.Sleep(60 * time.Millisecond)
+				c.String(http.StatusOK, "slow")
+			}},
+			want:     http.StatusOK,
+			wantBody: "slow",
+		},
+		{
+			name: "operation timeout",
+			cfg: TimeoutConfig{Timeout: time.Second, Operations: []OperationTimeout{
+				{Pattern: "sys:reports:*", Timeout: 20 * time.Millisecond},
+			}},
+			handlers: []gin.HandlerFunc{SetOperationID("sys:reports:export"), lateWrite},
+			want:     http.StatusGatewayTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(TimeoutWithConfig(tt.cfg))
+			r.GET("/", tt.handlers...)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("body = %s, want %q", w.Body, tt.wantBody)
+			}
+			if tt.want >= http.StatusInternalServerError {
+				if strings.Contains(w.Body.String(), "late") || w.Header().Get("X-Late") != "" {
+					t.Fatalf("late write leaked into response: %v %s", w.Header(), w.Body)
+				}
+				// 中间件等待处理链结束后才返回，迟到写入此时已完成
+				select {
+				case err := <-lateErr:
+					if err != http.ErrHandlerTimeout {
+						t.Fatalf("late write error = %v, want http.ErrHandlerTimeout", err)
+					}
+				default:
+					t.Fatal("handler still running after middleware returned")
+				}
+			}
+		})
+	}
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var (
+		recovered any
+		stack     string
+	)
+	r := gin.New()
+	r.Use(RecoveryWithConfig(RecoveryConfig{Logger: slog.New(slog.DiscardHandler), OnPanic: func(_ *gin.Context, rec any, s []byte) {
+		recovered, stack = rec, string(s)
+	}}), Timeout(time.Second))
+	r.GET("/", func(c *gin.Context) {
+		c.Header("X-Partial", "1")
+		panicInHandler()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	if w.Header().Get("X-Partial") != "" {
+		t.Fatalf("buffered header leaked: %v", w.Header())
+	}
+	if recovered != "boom" {
+		t.Fatalf("recovered = %#v, want original panic value", recovered)
+	}
+	// 调用栈来自处理链 goroutine，包含 panic 发生的位置
+	if !strings.Contains(stack, "panicInHandler") {
+		t.Fatalf("stack does not contain panic site:\n%s", stack)
+	}
+}
+
+func panicInHandler() {
+	panic("boom")
+}
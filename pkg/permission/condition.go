@@ -0,0 +1,720 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attributes 条件求值使用的属性。
+//
+// 约定的顶层键：
+//   - subject:  请求主体，如 {"id": "123", "org": "acme", "team": "dev"}
+//   - resource: 目标资源，如 {"owner": "123", "amount": 500}
+//   - env:      请求环境，如 {"ip": "10.0.0.8", "time": time.Now()}
+//
+// 值可以是嵌套的 map[string]any 或 map[string]string，
+// 表达式使用点号访问，如 resource.owner、env.ip。
+type Attributes map[string]any
+
+// 约定的属性顶层键。
+const (
+	AttrSubject  = "subject"
+	AttrResource = "resource"
+	AttrEnv      = "env"
+)
+
+// Lookup 按点号路径查找属性值。
+func (a Attributes) Lookup(path string) (any, bool) {
+	var cur any = map[string]any(a)
+	for key := range strings.SplitSeq(path, ".") {
+		switch m := cur.(type) {
+		case map[string]any:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case Attributes:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case map[string]string:
+			v, ok := m[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		default:
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
+
+// Condition 编译后的条件表达式。
+//
+// 表达式语言刻意保持很小，不支持赋值、循环或任意函数调用：
+//
+//	字面量    "abc" 'abc' 123 1.5 true false [1, 2, 3]
+//	属性      subject.id  resource.owner  env.ip
+//	变量      @me（= subject.id） @org（= subject.org） @team（= subject.team）
+//	比较      == != < <= > >= in
+//	逻辑      && || ! ( )
+//	函数      hour(t) weekday(t) cidr(ip, "10.0.0.0/8") prefix(s, "abc")
+//
+// 示例：
+//
+//	resource.owner == @me && resource.amount < 1000
+//	hour(env.time) >= 9 && hour(env.time) < 18 && weekday(env.time) in [1, 2, 3, 4, 5]
+//	cidr(env.ip, "10.0.0.0/8") || subject.role in ["admin", "ops"]
+//
+// 缺失属性语义：表达式引用的任一属性不存在（或类型不匹配）时，结果未知，[Condition.Eval] 返回 false。
+// 因此 !(resource.owner == @me) 在 resource.owner 缺失时同样为 false，而不是 true。
+// 语句评估时，结果未知的 allow 语句不命中，deny 语句视为命中（见 [Statement.MatchesAttributes]）；
+// 带条件的角色在结果未知时不生效（见 [RoleRegistry.ExpandWithAttributes]）。
+type Condition struct {
+	src  string
+	root condNode
+}
+
+// CompileCondition 编译条件表达式。
+func CompileCondition(expr string) (*Condition, error) {
+	p := condParser{src: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Condition{src: expr, root: root}, nil
+}
+
+// MustCompileCondition 同 [CompileCondition]，编译失败时 panic。
+func MustCompileCondition(expr string) *Condition {
+	c, err := CompileCondition(expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// String 返回原始表达式。
+func (c *Condition) String() string { return c.src }
+
+// Eval 使用给定属性求值，任何缺失属性或类型错误都返回 false。
+func (c *Condition) Eval(attrs Attributes) bool {
+	v, _ := c.evalKnown(attrs)
+	return v
+}
+
+// evalKnown 求值，known 为 false 表示因属性缺失或类型错误无法确定结果（此时 v 为 false）。
+func (c *Condition) evalKnown(attrs Attributes) (v, known bool) {
+	if c == nil {
+		return true, true
+	}
+	b, err := evalBool(c.root, attrs)
+	if err != nil {
+		return false, false
+	}
+	return b, true
+}
+
+// maxCachedConditions 条件缓存的容量上限，超出时整体清空。
+const maxCachedConditions = 1024
+
+// conditionCache 缓存已编译的条件，键为表达式原文。
+//
+// 条件来自配置文件，数量通常很少；容量上限防止动态构造的表达式使缓存无限增长。
+var conditionCache = struct {
+	sync.RWMutex
+	m map[string]*Condition
+}{m: make(map[string]*Condition)}
+
+// compileCached 编译并缓存条件表达式。
+func compileCached(expr string) (*Condition, error) {
+	conditionCache.RLock()
+	c, ok := conditionCache.m[expr]
+	conditionCache.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	c, err := CompileCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	conditionCache.Lock()
+	if len(conditionCache.m) >= maxCachedConditions {
+		clear(conditionCache.m)
+	}
+	conditionCache.m[expr] = c
+	conditionCache.Unlock()
+	return c, nil
+}
+
+// ============================================================================
+// 求值
+// ============================================================================
+
+// errMissing 属性缺失或类型不匹配，导致条件结果未知。
+var errMissing = errors.New("permission: condition attribute missing")
+
+// condNode 表达式语法树节点。
+type condNode interface {
+	eval(attrs Attributes) (any, error)
+}
+
+type (
+	litNode  struct{ v any }
+	pathNode struct{ path string }
+	listNode struct{ items []condNode }
+	notNode  struct{ x condNode }
+	andNode  struct{ l, r condNode }
+	orNode   struct{ l, r condNode }
+	cmpNode  struct {
+		op   string
+		l, r condNode
+	}
+	callNode struct {
+		fn   string
+		args []condNode
+	}
+)
+
+func (n litNode) eval(Attributes) (any, error) { return n.v, nil }
+
+func (n pathNode) eval(attrs Attributes) (any, error) {
+	v, ok := attrs.Lookup(n.path)
+	if !ok {
+		return nil, errMissing
+	}
+	return normalize(v), nil
+}
+
+func (n listNode) eval(attrs Attributes) (any, error) {
+	out := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(attrs)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (n notNode) eval(attrs Attributes) (any, error) {
+	b, err := evalBool(n.x, attrs)
+	if err != nil {
+		return nil, err
+	}
+	return !b, nil
+}
+
+func (n andNode) eval(attrs Attributes) (any, error) {
+	l, err := evalBool(n.l, attrs)
+	if err != nil || !l {
+		return false, err
+	}
+	return evalBool(n.r, attrs)
+}
+
+func (n orNode) eval(attrs Attributes) (any, error) {
+	l, err := evalBool(n.l, attrs)
+	if err != nil {
+		return nil, err
+	}
+	if l {
+		return true, nil
+	}
+	return evalBool(n.r, attrs)
+}
+
+func (n cmpNode) eval(attrs Attributes) (any, error) {
+	l, err := n.l.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.eval(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		list, ok := r.([]any)
+		if !ok {
+			return nil, errMissing
+		}
+		for _, item := range list {
+			if equal(l, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	c, err := compare(l, r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default: // ">="
+		return c >= 0, nil
+	}
+}
+
+func (n callNode) eval(attrs Attributes) (any, error) {
+	args := make([]any, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(attrs)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch n.fn {
+	case "hour", "weekday":
+		t, ok := args[0].(time.Time)
+		if !ok {
+			return nil, errMissing
+		}
+		if n.fn == "hour" {
+			return float64(t.Hour()), nil
+		}
+		return float64(t.Weekday()), nil
+	case "cidr":
+		ip, ok1 := args[0].(string)
+		cidr, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, errMissing
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return nil, errMissing
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, errMissing
+		}
+		return prefix.Contains(addr.Unmap()), nil
+	default: // "prefix"
+		s, ok1 := args[0].(string)
+		p, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, errMissing
+		}
+		return strings.HasPrefix(s, p), nil
+	}
+}
+
+// condFuncs 支持的函数及其参数个数。
+var condFuncs = map[string]int{
+	"hour":    1,
+	"weekday": 1,
+	"cidr":    2,
+	"prefix":  2,
+}
+
+// cmpOps 比较运算符（in 作为标识符单独处理）。
+var cmpOps = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// condVars 表达式变量到属性路径的映射。
+var condVars = map[string]string{
+	VarMe:   AttrSubject + ".id",
+	VarOrg:  AttrSubject + ".org",
+	VarTeam: AttrSubject + ".team",
+}
+
+func evalBool(n condNode, attrs Attributes) (bool, error) {
+	v, err := n.eval(attrs)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, errMissing
+	}
+	return b, nil
+}
+
+// normalize 将属性值统一为 string、float64、bool、time.Time 或 []any。
+func normalize(v any) any {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case fmt.Stringer:
+		if _, isTime := v.(time.Time); isTime {
+			return v
+		}
+		return x.String()
+	case []string:
+		out := make([]any, len(x))
+		for i, s := range x {
+			out[i] = s
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, item := range x {
+			out[i] = normalize(item)
+		}
+		return out
+	}
+	return v
+}
+
+// equal 比较两个值是否相等，类型不同视为不等。
+func equal(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	switch a.(type) {
+	case string, float64, bool:
+		return a == b
+	}
+	return false
+}
+
+// compare 比较两个同类型的有序值（数字、字符串、时间）。
+func compare(a, b any) (int, error) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return cmpOrdered(x, y), nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y), nil
+		}
+	}
+	return 0, errMissing
+}
+
+func cmpOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ============================================================================
+// 词法与语法分析
+// ============================================================================
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokVar
+	tokString
+	tokNumber
+	tokOp
+)
+
+type condToken struct {
+	kind tokKind
+	text string
+	val  any
+	pos  int
+}
+
+// condParser 递归下降解析器。
+type condParser struct {
+	src  string
+	toks []condToken
+	i    int
+}
+
+func (p *condParser) errorf(tok condToken, format string, args ...any) error {
+	return fmt.Errorf("permission: condition %q at %d: %s", p.src, tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+// tokenize 将表达式切分为 token。
+func (p *condParser) tokenize() error {
+	s := p.src
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return p.errorf(condToken{pos: i}, "unterminated string")
+			}
+			raw := s[i : j+1]
+			if c == '\'' {
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			v, err := strconv.Unquote(raw)
+			if err != nil {
+				return p.errorf(condToken{pos: i}, "invalid string %s", s[i:j+1])
+			}
+			p.toks = append(p.toks, condToken{kind: tokString, text: s[i : j+1], val: v, pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			v, err := strconv.ParseFloat(s[i:j], 64)
+			if err != nil {
+				return p.errorf(condToken{pos: i}, "invalid number %q", s[i:j])
+			}
+			p.toks = append(p.toks, condToken{kind: tokNumber, text: s[i:j], val: v, pos: i})
+			i = j
+		case isIdentByte(c) || c == '@':
+			j := i + 1
+			for j < len(s) && (isIdentByte(s[j]) || s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			kind := tokIdent
+			if c == '@' {
+				kind = tokVar
+			}
+			p.toks = append(p.toks, condToken{kind: kind, text: s[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, cand := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","} {
+				if strings.HasPrefix(s[i:], cand) {
+					op = cand
+					break
+				}
+			}
+			if op == "" {
+				return p.errorf(condToken{pos: i}, "unexpected character %q", c)
+			}
+			p.toks = append(p.toks, condToken{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	p.toks = append(p.toks, condToken{kind: tokEOF, text: "EOF", pos: len(s)})
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func (p *condParser) peek() condToken { return p.toks[p.i] }
+
+func (p *condParser) next() condToken {
+	tok := p.toks[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *condParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *condParser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q, got %q", op, tok.text)
+	}
+	return nil
+}
+
+func (p *condParser) parseOr() (condNode, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orNode{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *condParser) parseAnd() (condNode, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = andNode{l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *condParser) parseNot() (condNode, error) {
+	if p.accept("!") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x: x}, nil
+	}
+	return p.parseCmp()
+}
+
+func (p *condParser) parseCmp() (condNode, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	switch {
+	case tok.kind == tokOp && cmpOps[tok.text], tok.kind == tokIdent && tok.text == "in":
+		p.i++
+		r, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return cmpNode{op: tok.text, l: l, r: r}, nil
+	}
+	return l, nil
+}
+
+func (p *condParser) parsePrimary() (condNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString, tokNumber:
+		return litNode{v: tok.val}, nil
+	case tokVar:
+		path, ok := condVars[tok.text]
+		if !ok {
+			return nil, p.errorf(tok, "unknown variable %q", tok.text)
+		}
+		return pathNode{path: path}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return litNode{v: true}, nil
+		case "false":
+			return litNode{v: false}, nil
+		}
+		if p.accept("(") {
+			return p.parseCall(tok)
+		}
+		if strings.HasPrefix(tok.text, ".") || strings.HasSuffix(tok.text, ".") || strings.Contains(tok.text, "..") {
+			return nil, p.errorf(tok, "invalid attribute path %q", tok.text)
+		}
+		return pathNode{path: tok.text}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			var items []condNode
+			for !p.accept("]") {
+				if len(items) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				item, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return listNode{items: items}, nil
+		}
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *condParser) parseCall(name condToken) (condNode, error) {
+	arity, ok := condFuncs[name.text]
+	if !ok {
+		return nil, p.errorf(name, "unknown function %q", name.text)
+	}
+	var args []condNode
+	for !p.accept(")") {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) != arity {
+		return nil, p.errorf(name, "%s expects %d argument(s), got %d", name.text, arity, len(args))
+	}
+	return callNode{fn: name.text, args: args}, nil
+}
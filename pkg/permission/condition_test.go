@@ -0,0 +1,236 @@
+package permission
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCompileCondition(t *testing.T) {
+	valid := []string{
+		`resource.owner == @me`,
+		`resource.owner == @me && resource.amount < 1000`,
+		`hour(env.time) >= 9 && weekday(env.time) in [1, 2, 3, 4, 5]`,
+		`cidr(env.ip, "10.0.0.0/8") || subject.role in ["admin", 'ops']`,
+		`!(resource.status == "archived")`,
+		`prefix(resource.path, "/public/") && true`,
+		`resource.ratio >= 1.5`,
+	}
+	for _, expr := range valid {
+		if _, err := CompileCondition(expr); err != nil {
+			t.Errorf("CompileCondition(%q) error: %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`resource.owner ==`,
+		`resource.owner = @me`,
+		`(subject.id == "1"`,
+		`unknown(subject.id)`,
+		`cidr(env.ip)`,
+		`subject.id == "1" extra`,
+		`"unterminated`,
+		`@nobody == "1"`,
+		`[1, 2`,
+	}
+	for _, expr := range invalid {
+		if _, err := CompileCondition(expr); err == nil {
+			t.Errorf("CompileCondition(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	// 2026-01-05 是星期一
+	monday10 := time.Date(2026, 1, 5, 10, 30, 0, 0, time.UTC)
+	attrs := Attributes{
+		AttrSubject: map[string]any{"id": "7", "org": "acme", "role": "ops"},
+		AttrResource: map[string]any{
+			"owner":  "7",
+			"amount": 999,
+			"ratio":  float32(1.5),
+			"tags":   []string{"a", "b"},
+			"meta":   map[string]any{"level": int64(3)},
+			"path":   "/public/docs",
+		},
+		AttrEnv: map[string]any{"ip": "10.1.2.3", "time": monday10},
+	}
+
+	tests := []struct {
+		expr      string
+		attrs     Attributes
+		want      bool
+		wantKnown bool
+	}{
+		{`resource.owner == @me`, attrs, true, true},
+		{`resource.owner != @me`, attrs, false, true},
+		{`resource.amount < 1000`, attrs, true, true},
+		{`resource.amount <= 998`, attrs, false, true},
+		{`resource.ratio >= 1.5 && resource.ratio > 1`, attrs, true, true},
+		{`resource.meta.level == 3`, attrs, true, true},
+		{`subject.role in ["admin", "ops"]`, attrs, true, true},
+		{`subject.role in ["admin"]`, attrs, false, true},
+		{`cidr(env.ip, "10.0.0.0/8")`, attrs, true, true},
+		{`cidr(env.ip, "192.168.0.0/16")`, attrs, false, true},
+		{`hour(env.time) >= 9 && hour(env.time) < 18`, attrs, true, true},
+		{`weekday(env.time) in [1, 2, 3, 4, 5]`, attrs, true, true},
+		{`prefix(resource.path, "/public/")`, attrs, true, true},
+		{`!(resource.owner == "8")`, attrs, true, true},
+		{`subject.org == @org && !false`, attrs, true, true},
+
+		// 缺失属性：结果未知，Eval 返回 false
+		{`resource.missing == "x"`, attrs, false, false},
+		{`!(resource.missing == "x")`, attrs, false, false},
+		{`resource.missing != "x"`, attrs, false, false},
+		{`@team == "dev"`, attrs, false, false},
+		{`resource.owner == @me`, nil, false, false},
+		{`resource.owner.name == "x"`, attrs, false, false},
+
+		// 类型不匹配：结果未知
+		{`resource.owner < 10`, attrs, false, false},
+		{`hour(env.ip) == 1`, attrs, false, false},
+		{`cidr(env.ip, "not-a-cidr")`, attrs, false, false},
+		{`subject.role in subject.id`, attrs, false, false},
+
+		// 逻辑运算短路：已能确定结果时不受缺失属性影响
+		{`false && resource.missing == "x"`, attrs, false, true},
+		{`true || resource.missing == "x"`, attrs, true, true},
+		{`resource.missing == "x" || true`, attrs, false, false},
+	}
+
+	for _, tt := range tests {
+		c, err := CompileCondition(tt.expr)
+		if err != nil {
+			t.Fatalf("CompileCondition(%q): %v", tt.expr, err)
+		}
+		got, known := c.evalKnown(tt.attrs)
+		if got != tt.want || known != tt.wantKnown {
+			t.Errorf("%q = (%v, known=%v), want (%v, known=%v)", tt.expr, got, known, tt.want, tt.wantKnown)
+		}
+		if c.Eval(tt.attrs) != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, !tt.want, tt.want)
+		}
+	}
+}
+
+func TestStatementConditionFailClosed(t *testing.T) {
+	present := Attributes{AttrResource: map[string]any{"locked": true}}
+	absent := Attributes{}
+
+	tests := []struct {
+		name  string
+		stmt  Statement
+		attrs Attributes
+		want  bool
+	}{
+		{"allow with condition true", Statement{Effect: EffectAllow, Operations: []string{"*"}, Condition: `resource.locked == true`}, present, true},
+		{"allow with missing attribute", Statement{Effect: EffectAllow, Operations: []string{"*"}, Condition: `resource.locked == true`}, absent, false},
+		{"allow with nil attributes", Statement{Effect: EffectAllow, Operations: []string{"*"}, Condition: `resource.locked == true`}, nil, false},
+		{"allow with invalid condition", Statement{Effect: EffectAllow, Operations: []string{"*"}, Condition: `resource.locked ==`}, present, false},
+		{"deny with condition true", Statement{Effect: EffectDeny, Operations: []string{"*"}, Condition: `resource.locked == true`}, present, true},
+		{"deny with condition false", Statement{Effect: EffectDeny, Operations: []string{"*"}, Condition: `resource.locked == false`}, present, false},
+		{"deny with missing attribute", Statement{Effect: EffectDeny, Operations: []string{"*"}, Condition: `resource.locked == false`}, absent, true},
+		{"deny with negated missing attribute", Statement{Effect: EffectDeny, Operations: []string{"*"}, Condition: `!(resource.owner == @me)`}, absent, true},
+		{"deny with invalid condition", Statement{Effect: EffectDeny, Operations: []string{"*"}, Condition: `resource.locked ==`}, present, true},
+		{"deny with other operation", Statement{Effect: EffectDeny, Operations: []string{"sys:users:*"}, Condition: `resource.locked == false`}, absent, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stmt.MatchesAttributes("sys:orders:update", "", tt.attrs); got != tt.want {
+				t.Fatalf("MatchesAttributes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleCondition(t *testing.T) {
+	reg, err := NewRoleRegistry(
+		Role{Name: "viewer", Operations: []string{"sys:*:read"}},
+		Role{Name: "owner", Operations: []string{"org.*:orders:update"}, Inherits: []string{"viewer"},
+			Condition: `resource.owner == @me`},
+		Role{Name: "staff", Operations: []string{"sys:tickets:*"}, Inherits: []string{"viewer"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owned := Attributes{
+		AttrSubject:  map[string]any{"id": "7"},
+		AttrResource: map[string]any{"owner": "7"},
+	}
+	other := Attributes{
+		AttrSubject:  map[string]any{"id": "7"},
+		AttrResource: map[string]any{"owner": "8"},
+	}
+
+	tests := []struct {
+		name  string
+		attrs Attributes
+		roles []string
+		want  []string
+	}{
+		{"condition true", owned, []string{"owner"}, []string{"org.*:orders:update", "sys:*:read"}},
+		{"condition false skips inherited roles", other, []string{"owner"}, nil},
+		{"missing attributes", nil, []string{"owner"}, nil},
+		{"inherited via another role", other, []string{"owner", "staff"}, []string{"sys:tickets:*", "sys:*:read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := reg.ExpandWithAttributes(tt.attrs, tt.roles...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(g.Operations, tt.want) {
+				t.Fatalf("Operations = %v, want %v", g.Operations, tt.want)
+			}
+		})
+	}
+
+	// Expand 不带属性：带条件的角色不生效
+	g, err := reg.Expand("owner")
+	if err != nil || len(g.Operations) != 0 {
+		t.Fatalf("Expand(owner) = %v, %v; want no operations", g.Operations, err)
+	}
+}
+
+func TestConditionCacheBounded(t *testing.T) {
+	for i := range maxCachedConditions + 10 {
+		if _, err := compileCached(fmt.Sprintf(`subject.id == "%d"`, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conditionCache.RLock()
+	n := len(conditionCache.m)
+	conditionCache.RUnlock()
+	if n > maxCachedConditions {
+		t.Fatalf("cache size = %d, want <= %d", n, maxCachedConditions)
+	}
+}
+
+func TestStatementResourceUnresolved(t *testing.T) {
+	scoped := Statement{Effect: EffectAllow, Operations: []string{"sys:users:delete"}, Resources: []string{"sys:users:42"}}
+	unscoped := Statement{Effect: EffectAllow, Operations: []string{"sys:users:delete"}}
+
+	tests := []struct {
+		name string
+		stmt Statement
+		res  Resource
+		want bool
+	}{
+		{"scoped without resource", scoped, "", false},
+		{"scoped matching resource", scoped, "sys:users:42", true},
+		{"scoped other resource", scoped, "sys:users:43", false},
+		{"unscoped without resource", unscoped, "", true},
+		{"unscoped with resource", unscoped, "sys:users:43", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stmt.Matches("sys:users:delete", tt.res); got != tt.want {
+				t.Fatalf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
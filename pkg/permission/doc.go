@@ -7,6 +7,14 @@
 //   - [Operation]: 操作标识符 {scope}:{type}:{action}
 //   - [Resource]: 资源标识符 {scope}:{type}:{id}
 //   - [Resolver]: 运行时变量解析器（@me, @org, @team）
+//   - [OperationRegistry]: 操作目录，记录显示名称、分组与风险等级，可发现失效授权
+//   - [PermissionSet]: 预编译的模式集合，适用于大量授权模式的快速匹配
+//   - [RoleRegistry]: 角色注册表，支持继承并展开为授权模式
+//   - [Policy]: 授权策略，由 allow/deny 语句组成，显式拒绝优先
+//   - [Condition]: 角色与语句上的条件表达式，基于 [Attributes] 求值（ABAC）
+//   - [Explanation]: 权限判定过程的解释（见 [Explain]），用于排查拒绝原因
+//   - [Document]: 从 YAML/JSON 文件加载的角色与策略（见 [LoadFile]）
+//   - [PolicyStore]: 可热加载的策略存储，以 [Snapshot] 提供一致的只读版本
 //
 // # 使用方式
 //
@@ -22,12 +30,25 @@
 //	    // 有权限
 //	}
 //
+// 使用策略表达 "允许 sys 域所有操作，但禁止删除用户"：
+//
+//	p := permission.Policy{Statements: []permission.Statement{
+//	    {Effect: permission.EffectAllow, Operations: []string{"sys:*:*"}},
+//	    {Effect: permission.EffectDeny, Operations: []string{"sys:users:delete"}},
+//	}}
+//	d := p.Evaluate("sys:users:delete", "")
+//	d.Allowed // false
+//	d.Reason  // ReasonExplicitDeny
+//
 // 运行时变量替换（用于资源权限）：
 //
 //	r := permission.NewResolver(map[string]string{"@me": "123", "@org": "acme"})
 //	resource := r.ResolveResource("self:user:@me")     // "self:user:123"
 //	resource = r.ResolveResource("org.@org:team:*")    // "org.acme:team:*"
 //
+// 变量按 token 整体替换（以 : 与 . 切分），需要感知未解析变量或不安全取值时
+// 使用 [Resolver.ResolveStrict]。
+//
 // # URN 格式
 //
 // 三段式结构：{scope}:{type}:{identifier}
@@ -44,6 +65,12 @@
 //   - sys:*:* 匹配 sys 域所有操作
 //   - sys.*:*:* 匹配 sys 及其子域（如 sys.admin）
 //
+// 严格解析：
+//
+// [MatchOperation] 等匹配函数对缺省段宽松处理（"sys" 视为 "sys:*:*"），
+// 来自配置或用户输入的 URN 应使用 [ParseOperation]、[ParseResource] 严格校验，
+// 包级常量可使用 [MustParseOperation]、[MustParseResource]。
+//
 // # 设计原则
 //
 // 本包是通用库，可复用于 HTTP、CLI、gRPC 等任何场景。
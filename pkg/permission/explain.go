@@ -0,0 +1,121 @@
+package permission
+
+// Segment URN 段名称。
+type Segment string
+
+const (
+	// SegmentScope 第一段，作用域（如 sys、org.acme）。
+	SegmentScope Segment = "scope"
+	// SegmentType 第二段，资源类型（如 users）。
+	SegmentType Segment = "type"
+	// SegmentIdentifier 第三段，操作或资源标识（如 create、42）。
+	SegmentIdentifier Segment = "identifier"
+)
+
+// Substitution 一次变量替换。
+type Substitution struct {
+	Variable string `json:"variable"` // 变量名，如 @me
+	Value    string `json:"value"`    // 替换后的值
+}
+
+// PatternTrace 单个授权模式的匹配过程。
+type PatternTrace struct {
+	Pattern       string         `json:"pattern"`                  // 原始模式
+	Resolved      string         `json:"resolved"`                 // 变量替换后的模式
+	Substitutions []Substitution `json:"substitutions,omitempty"`  // 发生的变量替换
+	Matched       bool           `json:"matched"`                  // 是否命中
+	FailedSegment Segment        `json:"failed_segment,omitempty"` // 未命中时第一个失败的段
+	Error         string         `json:"error,omitempty"`          // 变量解析错误（此时模式不参与匹配）
+}
+
+// Explanation 权限判定的完整解释，用于排查 "为什么被拒绝"。
+type Explanation struct {
+	Target   string         `json:"target"`            // 被检查的操作或资源
+	Allowed  bool           `json:"allowed"`           // 最终结果
+	Matched  string         `json:"matched,omitempty"` // 第一个命中的模式
+	Reason   string         `json:"reason,omitempty"`  // 额外说明（如被策略显式拒绝）
+	Patterns []PatternTrace `json:"patterns"`          // 逐个模式的匹配过程
+}
+
+// Explain 解释授权模式列表对目标操作/资源的判定过程。
+//
+// 与中间件逐个调用 [Resolver.ResolveStrict] 与 [MatchOperation] 的结果一致，但会记录：
+//   - 每个模式的变量替换，以及 [Resolver.ResolveStrict] 报告的解析错误
+//   - 未命中时第一个失败的段（scope → type → identifier）
+//   - 最终判定与命中的模式
+//
+// 示例：
+//
+//	e := permission.Explain("sys:users:delete", []string{"sys:users:read", "self:*:*"}, nil)
+//	e.Allowed                   // false
+//	e.Patterns[0].FailedSegment // "identifier"
+//	e.Patterns[1].FailedSegment // "scope"
+func Explain(target string, grants []string, r *Resolver) *Explanation {
+	e := &Explanation{
+		Target:   target,
+		Patterns: make([]PatternTrace, 0, len(grants)),
+	}
+	for _, pattern := range grants {
+		resolved, subs, err := r.resolve(pattern)
+		t := PatternTrace{
+			Pattern:       pattern,
+			Resolved:      resolved,
+			Substitutions: subs,
+		}
+		if err != nil {
+			t.Error = err.Error()
+			e.Patterns = append(e.Patterns, t)
+			continue
+		}
+		t.FailedSegment = failedSegment(t.Resolved, target)
+		t.Matched = t.FailedSegment == ""
+		if t.Matched && !e.Allowed {
+			e.Allowed = true
+			e.Matched = pattern
+		}
+		e.Patterns = append(e.Patterns, t)
+	}
+	return e
+}
+
+// Redact 返回去除变量取值的副本，适合返回给客户端。
+//
+// 替换后的值（如用户 ID、组织 ID）被隐藏，Resolved 还原为原始模式。
+func (e *Explanation) Redact() *Explanation {
+	if e == nil {
+		return nil
+	}
+	out := *e
+	out.Patterns = make([]PatternTrace, len(e.Patterns))
+	for i, t := range e.Patterns {
+		t.Resolved = t.Pattern
+		if len(t.Substitutions) > 0 {
+			subs := make([]Substitution, len(t.Substitutions))
+			for j, s := range t.Substitutions {
+				subs[j] = Substitution{Variable: s.Variable, Value: "[redacted]"}
+			}
+			t.Substitutions = subs
+		}
+		out.Patterns[i] = t
+	}
+	return &out
+}
+
+// failedSegment 返回模式匹配目标时第一个失败的段，命中时返回空。
+//
+// 判定顺序与 [match] 一致。
+func failedSegment(pattern, target string) Segment {
+	if match(pattern, target) {
+		return ""
+	}
+	p := parseURN(pattern)
+	t := parseURN(target)
+	switch {
+	case !matchScope(p.Scope, t.Scope):
+		return SegmentScope
+	case p.Type != "*" && p.Type != t.Type:
+		return SegmentType
+	default:
+		return SegmentIdentifier
+	}
+}
@@ -0,0 +1,257 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// Document 权限配置文件内容。
+//
+// 文件格式（YAML，JSON 同构）：
+//
+//	roles:
+//	  - name: viewer
+//	    operations: ["sys:*:read"]
+//	  - name: admin
+//	    inherits: [viewer]
+//	    operations: ["sys:*:*"]
+//	  - name: order-owner
+//	    operations: ["org.*:orders:update"]
+//	    condition: resource.owner == @me && resource.amount < 1000
+//	policies:
+//	  - id: protect-users
+//	    statements:
+//	      - effect: deny
+//	        operations: ["sys:users:delete"]
+//	      - effect: deny
+//	        operations: ["sys:*:*"]
+//	        condition: '!cidr(env.ip, "10.0.0.0/8")'
+type Document struct {
+	Roles    []Role   `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Policies []Policy `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// RoleRegistry 使用文档中的角色构造 [RoleRegistry]。
+func (d *Document) RoleRegistry() (*RoleRegistry, error) {
+	return NewRoleRegistry(d.Roles...)
+}
+
+// LoadFile 读取并校验权限配置文件（.yaml、.yml 或 .json）。
+//
+// 校验失败时返回的错误包含文件名、行号与字段路径，多个错误使用 [errors.Join] 合并。
+func LoadFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return load(path, data, true)
+}
+
+// Load 解析并校验权限配置内容。
+//
+// YAML 是 JSON 的超集，因此 data 可以是 YAML 或 JSON。
+func Load(data []byte) (*Document, error) {
+	return load("", data, true)
+}
+
+// load 解析、解码并校验配置内容。
+//
+// refs 为 false 时跳过角色继承引用与环检测，供多文件合并后统一检查。
+func load(name string, data []byte, refs bool) (*Document, error) {
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, wrapFileError(name, err)
+	}
+
+	var doc Document
+	if err := yaml.UnmarshalWithOptions(data, &doc, yaml.DisallowUnknownField()); err != nil {
+		return nil, wrapFileError(name, err)
+	}
+
+	v := validator{name: name, file: file, refs: refs}
+	v.validate(&doc)
+	if len(v.errs) > 0 {
+		return nil, errors.Join(v.errs...)
+	}
+	return &doc, nil
+}
+
+// checkRoles 检查合并后文档中的角色重名、未知继承与继承环。
+func checkRoles(doc *Document) error {
+	names := make(map[string]bool, len(doc.Roles))
+	for _, role := range doc.Roles {
+		if names[role.Name] {
+			return fmt.Errorf("permission: duplicate role %q", role.Name)
+		}
+		names[role.Name] = true
+	}
+	for _, role := range doc.Roles {
+		for _, parent := range role.Inherits {
+			if !names[parent] {
+				return fmt.Errorf("permission: role %q inherits unknown role %q", role.Name, parent)
+			}
+		}
+	}
+	_, err := doc.RoleRegistry()
+	return err
+}
+
+// wrapFileError 为解析错误附加文件名与位置信息。
+func wrapFileError(name string, err error) error {
+	msg := yaml.FormatError(err, false, false)
+	if name == "" {
+		return fmt.Errorf("permission: %s", msg)
+	}
+	return fmt.Errorf("permission: %s: %s", name, msg)
+}
+
+// ============================================================================
+// 校验
+// ============================================================================
+
+// FieldError 配置字段校验错误。
+type FieldError struct {
+	File   string // 文件名，从内存加载时为空
+	Line   int    // 行号（从 1 开始），无法定位时为 0
+	Column int    // 列号（从 1 开始），无法定位时为 0
+	Path   string // 字段路径，如 $.roles[0].operations[1]
+	Msg    string // 错误描述
+}
+
+// Error 实现 error 接口。
+//
+// 格式：{file}:{line}:{column}: {path}: {msg}
+func (e *FieldError) Error() string {
+	var b strings.Builder
+	b.WriteString("permission: ")
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", e.Line, e.Column)
+	}
+	if b.Len() > len("permission: ") {
+		b.WriteString(" ")
+	}
+	b.WriteString(e.Path)
+	b.WriteString(": ")
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// validator 收集文档中的全部校验错误。
+type validator struct {
+	name string
+	file *ast.File
+	refs bool
+	errs []error
+}
+
+func (v *validator) validate(doc *Document) {
+	names := make(map[string]bool, len(doc.Roles))
+	for _, role := range doc.Roles {
+		names[role.Name] = true
+	}
+
+	seen := make(map[string]bool, len(doc.Roles))
+	for i, role := range doc.Roles {
+		at := fmt.Sprintf("$.roles[%d]", i)
+		switch {
+		case strings.TrimSpace(role.Name) == "":
+			v.fail(at+".name", "role name is required")
+		case seen[role.Name]:
+			v.fail(at+".name", fmt.Sprintf("duplicate role %q", role.Name))
+		}
+		seen[role.Name] = true
+
+		for j, p := range role.Operations {
+			v.pattern(fmt.Sprintf("%s.operations[%d]", at, j), p)
+		}
+		for j, p := range role.Resources {
+			v.pattern(fmt.Sprintf("%s.resources[%d]", at, j), p)
+		}
+		for j, parent := range role.Inherits {
+			if v.refs && !names[parent] {
+				v.fail(fmt.Sprintf("%s.inherits[%d]", at, j), fmt.Sprintf("unknown role %q", parent))
+			}
+		}
+		if role.Condition != "" {
+			if _, err := compileCached(role.Condition); err != nil {
+				v.fail(at+".condition", strings.TrimPrefix(err.Error(), "permission: "))
+			}
+		}
+	}
+	if v.refs && len(v.errs) == 0 {
+		if _, err := doc.RoleRegistry(); err != nil {
+			v.fail("$.roles", strings.TrimPrefix(err.Error(), "permission: "))
+		}
+	}
+
+	for i, policy := range doc.Policies {
+		at := fmt.Sprintf("$.policies[%d]", i)
+		for j, stmt := range policy.Statements {
+			sat := fmt.Sprintf("%s.statements[%d]", at, j)
+			if !stmt.Effect.IsValid() {
+				v.fail(sat+".effect", fmt.Sprintf("invalid effect %q, want allow or deny", stmt.Effect))
+			}
+			if len(stmt.Operations) == 0 {
+				v.fail(sat, "at least one operation is required")
+			}
+			for k, p := range stmt.Operations {
+				v.pattern(fmt.Sprintf("%s.operations[%d]", sat, k), p)
+			}
+			for k, p := range stmt.Resources {
+				v.pattern(fmt.Sprintf("%s.resources[%d]", sat, k), p)
+			}
+			if stmt.Condition != "" {
+				if _, err := compileCached(stmt.Condition); err != nil {
+					v.fail(sat+".condition", strings.TrimPrefix(err.Error(), "permission: "))
+				}
+			}
+		}
+	}
+}
+
+// pattern 按 [ParseOperation] 的严格规则校验单个 URN 模式。
+//
+// 与 [MatchOperation] 一致，单独的 * 作为 *:*:* 的简写被接受。
+func (v *validator) pattern(at, s string) {
+	if s == "*" {
+		return
+	}
+	if err := parseStrict(s); err != nil {
+		v.fail(at, strings.TrimPrefix(err.Error(), "permission: "))
+	}
+}
+
+// fail 记录字段错误，并尽可能定位到源文件行列。
+func (v *validator) fail(at, msg string) {
+	e := &FieldError{File: v.name, Path: at, Msg: msg}
+	if node := v.lookup(at); node != nil {
+		if tk := node.GetToken(); tk != nil && tk.Position != nil {
+			e.Line = tk.Position.Line
+			e.Column = tk.Position.Column
+		}
+	}
+	v.errs = append(v.errs, e)
+}
+
+// lookup 按字段路径查找源文件中的节点，找不到时返回 nil。
+func (v *validator) lookup(at string) ast.Node {
+	path, err := yaml.PathString(at)
+	if err != nil {
+		return nil
+	}
+	node, err := path.FilterFile(v.file)
+	if err != nil {
+		return nil
+	}
+	return node
+}
@@ -0,0 +1,174 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidURN URN 格式无效。
+var ErrInvalidURN = errors.New("permission: invalid URN")
+
+// ParseError 严格解析 URN 失败的详细信息。
+//
+// 可使用 errors.Is(err, ErrInvalidURN) 判断。
+type ParseError struct {
+	Input   string // 原始输入
+	Segment string // 出错的段：scope、type、identifier，整体错误时为空
+	Msg     string // 错误描述
+}
+
+// Error 实现 error 接口。
+func (e *ParseError) Error() string {
+	if e.Segment == "" {
+		return fmt.Sprintf("permission: invalid URN %q: %s", e.Input, e.Msg)
+	}
+	return fmt.Sprintf("permission: invalid URN %q: %s: %s", e.Input, e.Segment, e.Msg)
+}
+
+// Unwrap 返回 [ErrInvalidURN]。
+func (e *ParseError) Unwrap() error { return ErrInvalidURN }
+
+// ParseOperation 严格解析操作标识符（或操作模式）。
+//
+// 与 [MatchOperation] 等宽松路径不同，缺省段不会被当作通配符：
+//
+//	ParseOperation("sys:users:create") // OK
+//	ParseOperation("sys.*:*:read")     // OK
+//	ParseOperation("sys")              // 错误：缺少 type 与 identifier
+//	ParseOperation("sys:users:")       // 错误：identifier 为空
+//	ParseOperation("org..team:x:y")    // 错误：scope 层级为空
+//
+// 校验规则：
+//   - 必须是 {scope}:{type}:{identifier} 三段，不多不少
+//   - 各段不能为空，不能包含空白
+//   - 仅允许字母、数字以及 _ - . * @
+//   - scope 各层级不能为空（禁止 org..team、.sys、sys.）
+//   - * 只能单独构成一段，或作为 scope 的最后一级（如 sys.*）
+//   - 变量只能是 @me、@org、@team，且必须单独构成 scope 层级或整段
+func ParseOperation(s string) (Operation, error) {
+	if err := parseStrict(s); err != nil {
+		return "", err
+	}
+	return Operation(s), nil
+}
+
+// ParseResource 严格解析资源标识符（或资源模式），规则同 [ParseOperation]。
+func ParseResource(s string) (Resource, error) {
+	if err := parseStrict(s); err != nil {
+		return "", err
+	}
+	return Resource(s), nil
+}
+
+// MustParseOperation 同 [ParseOperation]，解析失败时 panic。
+//
+// 适用于包级常量/变量初始化：
+//
+//	var UserCreate = permission.MustParseOperation("sys:users:create")
+func MustParseOperation(s string) Operation {
+	o, err := ParseOperation(s)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+// MustParseResource 同 [ParseResource]，解析失败时 panic。
+func MustParseResource(s string) Resource {
+	r, err := ParseResource(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// knownVars 严格解析允许的变量。
+var knownVars = map[string]bool{
+	VarMe:   true,
+	VarOrg:  true,
+	VarTeam: true,
+}
+
+// segmentNames URN 各段名称（用于错误信息）。
+var segmentNames = [3]string{"scope", "type", "identifier"}
+
+// parseStrict 按严格规则校验 URN。
+func parseStrict(s string) error {
+	fail := func(seg, format string, args ...any) error {
+		return &ParseError{Input: s, Segment: seg, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	if s == "*" {
+		return fail("", "use *:*:* for the super wildcard")
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return fail("", "want 3 segments {scope}:{type}:{identifier}, got %d", len(parts))
+	}
+
+	for i, seg := range parts {
+		name := segmentNames[i]
+		if seg == "" {
+			return fail(name, "empty segment")
+		}
+		for _, r := range seg {
+			if !isURNChar(r) {
+				return fail(name, "illegal character %q", r)
+			}
+		}
+
+		if i > 0 {
+			if err := checkToken(seg); err != "" {
+				return fail(name, "%s", err)
+			}
+			continue
+		}
+
+		// scope：按层级校验
+		levels := strings.Split(seg, ".")
+		for j, level := range levels {
+			if level == "" {
+				return fail(name, "empty level in %q", seg)
+			}
+			if level == "*" && len(levels) > 1 && j != len(levels)-1 {
+				return fail(name, "wildcard is only allowed as the last level, like sys.*")
+			}
+			if err := checkToken(level); err != "" {
+				return fail(name, "%s", err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkToken 校验单个段或 scope 层级中的通配符与变量，返回错误描述。
+func checkToken(tok string) string {
+	if tok == "*" {
+		return ""
+	}
+	if strings.Contains(tok, "*") {
+		return fmt.Sprintf("wildcard must be a whole token, got %q", tok)
+	}
+	if at := strings.IndexByte(tok, '@'); at >= 0 {
+		if at != 0 {
+			return fmt.Sprintf("variable must be a whole token, got %q", tok)
+		}
+		if !knownVars[tok] {
+			return fmt.Sprintf("unknown variable %q", tok)
+		}
+	}
+	return ""
+}
+
+// isURNChar 报告字符是否允许出现在 URN 中。
+func isURNChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '_', r == '-', r == '.', r == '*', r == '@':
+		return true
+	}
+	return false
+}
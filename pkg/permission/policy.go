@@ -0,0 +1,202 @@
+package permission
+
+import "fmt"
+
+// Effect 授权语句的效果。
+type Effect string
+
+const (
+	// EffectAllow 允许。
+	EffectAllow Effect = "allow"
+	// EffectDeny 显式拒绝，优先级高于任何 allow。
+	EffectDeny Effect = "deny"
+)
+
+// IsValid 报告效果是否为已知取值。
+//
+// 未知取值的语句在评估时既不允许也不拒绝。
+func (e Effect) IsValid() bool {
+	return e == EffectAllow || e == EffectDeny
+}
+
+// Statement 授权语句。
+//
+// 一条语句描述 "对哪些资源允许/拒绝哪些操作"：
+//   - Operations 为操作模式列表，任一模式匹配即视为操作命中
+//   - Resources 为资源模式列表，为空表示不限制资源；设置后只对给定的资源生效
+//
+// 模式语法与 [MatchOperation]、[MatchResource] 相同。
+//
+// Condition 为可选的条件表达式（语法见 [Condition]），
+// 设置后语句只在条件对请求属性求值为 true 时生效。
+type Statement struct {
+	ID         string   `json:"id,omitempty" yaml:"id,omitempty"`
+	Effect     Effect   `json:"effect" yaml:"effect"`
+	Operations []string `json:"operations" yaml:"operations"`
+	Resources  []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Condition  string   `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// Matches 报告语句是否同时命中操作与资源。
+//
+// res 为空时只有未限定 Resources 的语句可能命中。
+// 带条件的语句在没有属性时按结果未知处理，见 [Statement.MatchesAttributes]。
+func (s *Statement) Matches(op Operation, res Resource) bool {
+	return s.MatchesAttributes(op, res, nil)
+}
+
+// MatchesAttributes 报告语句是否命中操作与资源，且条件对 attrs 求值为 true。
+//
+// 条件因属性缺失、类型不匹配或表达式无法编译而无法确定结果时：
+// allow 语句视为不命中，deny 语句视为命中（失败时拒绝，避免缺少属性绕过拒绝规则）。
+func (s *Statement) MatchesAttributes(op Operation, res Resource, attrs Attributes) bool {
+	if !s.matchOperation(op) || !s.matchResource(res) {
+		return false
+	}
+	if s.Condition == "" {
+		return true
+	}
+	cond, err := compileCached(s.Condition)
+	if err != nil {
+		return s.Effect == EffectDeny
+	}
+	v, known := cond.evalKnown(attrs)
+	if !known {
+		return s.Effect == EffectDeny
+	}
+	return v
+}
+
+func (s *Statement) matchOperation(op Operation) bool {
+	for _, p := range s.Operations {
+		if MatchOperation(p, string(op)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Statement) matchResource(res Resource) bool {
+	if len(s.Resources) == 0 {
+		return true
+	}
+	if res == "" {
+		return false
+	}
+	for _, p := range s.Resources {
+		if MatchResource(p, string(res)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy 授权策略，由一组语句构成。
+//
+// 评估规则（与常见 IAM 模型一致）：
+//  1. 任一 deny 语句命中 → 拒绝（显式拒绝优先）
+//  2. 否则任一 allow 语句命中 → 允许
+//  3. 否则 → 拒绝（默认拒绝）
+//
+// 示例：允许 sys 域所有操作，但禁止删除用户：
+//
+//	p := permission.Policy{Statements: []permission.Statement{
+//	    {Effect: permission.EffectAllow, Operations: []string{"sys:*:*"}},
+//	    {Effect: permission.EffectDeny, Operations: []string{"sys:users:delete"}},
+//	}}
+//	p.Evaluate("sys:users:create", "").Allowed // true
+//	p.Evaluate("sys:users:delete", "").Allowed // false
+type Policy struct {
+	ID         string      `json:"id,omitempty" yaml:"id,omitempty"`
+	Statements []Statement `json:"statements" yaml:"statements"`
+}
+
+// Evaluate 评估操作与资源，返回结构化的决策结果。
+//
+// res 为空时限定了 Resources 的语句不命中。没有属性时带条件的 allow 语句不命中、deny 语句命中，
+// 需要条件时使用 [Policy.EvaluateWithAttributes]。
+func (p *Policy) Evaluate(op Operation, res Resource) Decision {
+	return p.EvaluateWithAttributes(op, res, nil)
+}
+
+// EvaluateWithAttributes 评估操作与资源，条件语句使用 attrs 求值。
+func (p *Policy) EvaluateWithAttributes(op Operation, res Resource, attrs Attributes) Decision {
+	if p == nil {
+		return Decision{Reason: ReasonNoMatch, Index: -1}
+	}
+	return evaluate(op, res, attrs, p.Statements)
+}
+
+// Evaluate 依次合并多个策略的语句后评估。
+//
+// 任一策略中的 deny 语句都会覆盖其他策略中的 allow 语句。
+func Evaluate(op Operation, res Resource, policies ...Policy) Decision {
+	return EvaluateWithAttributes(op, res, nil, policies...)
+}
+
+// EvaluateWithAttributes 同 [Evaluate]，条件语句使用 attrs 求值。
+func EvaluateWithAttributes(op Operation, res Resource, attrs Attributes, policies ...Policy) Decision {
+	var stmts []Statement
+	for i := range policies {
+		stmts = append(stmts, policies[i].Statements...)
+	}
+	return evaluate(op, res, attrs, stmts)
+}
+
+// evaluate 执行 deny 优先的语句评估。
+func evaluate(op Operation, res Resource, attrs Attributes, stmts []Statement) Decision {
+	allow := -1
+	for i := range stmts {
+		s := &stmts[i]
+		if !s.MatchesAttributes(op, res, attrs) {
+			continue
+		}
+		switch s.Effect {
+		case EffectDeny:
+			return Decision{Allowed: false, Reason: ReasonExplicitDeny, Statement: s, Index: i}
+		case EffectAllow:
+			if allow < 0 {
+				allow = i
+			}
+		}
+	}
+	if allow >= 0 {
+		return Decision{Allowed: true, Reason: ReasonExplicitAllow, Statement: &stmts[allow], Index: allow}
+	}
+	return Decision{Allowed: false, Reason: ReasonNoMatch, Index: -1}
+}
+
+// ============================================================================
+// 决策结果
+// ============================================================================
+
+// DecisionReason 决策原因。
+type DecisionReason string
+
+const (
+	// ReasonExplicitAllow 有 allow 语句命中，且无 deny 语句命中。
+	ReasonExplicitAllow DecisionReason = "explicit_allow"
+	// ReasonExplicitDeny 有 deny 语句命中。
+	ReasonExplicitDeny DecisionReason = "explicit_deny"
+	// ReasonNoMatch 没有任何语句命中（默认拒绝）。
+	ReasonNoMatch DecisionReason = "no_match"
+)
+
+// Decision 策略评估结果。
+type Decision struct {
+	Allowed   bool           // 是否允许
+	Reason    DecisionReason // 决策原因
+	Statement *Statement     // 决定结果的语句，无命中时为 nil
+	Index     int            // 决定结果的语句在评估列表中的下标，无命中时为 -1
+}
+
+// String 返回决策的可读描述。
+func (d Decision) String() string {
+	if d.Statement == nil {
+		return string(d.Reason)
+	}
+	if d.Statement.ID != "" {
+		return fmt.Sprintf("%s (statement %q)", d.Reason, d.Statement.ID)
+	}
+	return fmt.Sprintf("%s (statement #%d)", d.Reason, d.Index)
+}
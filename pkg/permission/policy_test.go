@@ -0,0 +1,129 @@
+package permission
+
+import "testing"
+
+func TestPolicyEvaluate(t *testing.T) {
+	p := Policy{Statements: []Statement{
+		{ID: "sys-all", Effect: EffectAllow, Operations: []string{"sys:*:*"}},
+		{ID: "no-delete", Effect: EffectDeny, Operations: []string{"sys:users:delete"}},
+		{ID: "orders", Effect: EffectAllow, Operations: []string{"org.*:orders:*"}},
+		{ID: "no-archive", Effect: EffectDeny, Operations: []string{"org.*:orders:update"}, Resources: []string{"org.acme:orders:archived"}},
+		{ID: "bogus", Effect: "maybe", Operations: []string{"sys:reports:*"}},
+	}}
+
+	tests := []struct {
+		name    string
+		op      Operation
+		res     Resource
+		allowed bool
+		reason  DecisionReason
+		stmt    string
+		index   int
+	}{
+		{"allow", "sys:users:create", "", true, ReasonExplicitAllow, "sys-all", 0},
+		{"deny overrides earlier allow", "sys:users:delete", "", false, ReasonExplicitDeny, "no-delete", 1},
+		{"no match", "self:profile:update", "", false, ReasonNoMatch, "", -1},
+		{"subscope allow", "org.acme:orders:update", "org.acme:orders:1", true, ReasonExplicitAllow, "orders", 2},
+		{"resource-scoped deny", "org.acme:orders:update", "org.acme:orders:archived", false, ReasonExplicitDeny, "no-archive", 3},
+		{"resource-scoped deny without resource", "org.acme:orders:update", "", true, ReasonExplicitAllow, "orders", 2},
+		{"unknown effect neither allows nor denies", "sys:reports:export", "", true, ReasonExplicitAllow, "sys-all", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.Evaluate(tt.op, tt.res)
+			if d.Allowed != tt.allowed || d.Reason != tt.reason || d.Index != tt.index {
+				t.Fatalf("Evaluate = %+v, want allowed=%v reason=%s index=%d", d, tt.allowed, tt.reason, tt.index)
+			}
+			id := ""
+			if d.Statement != nil {
+				id = d.Statement.ID
+			}
+			if id != tt.stmt {
+				t.Fatalf("Statement = %q, want %q", id, tt.stmt)
+			}
+		})
+	}
+}
+
+func TestEvaluateAcrossPolicies(t *testing.T) {
+	base := Policy{ID: "base", Statements: []Statement{
+		{Effect: EffectAllow, Operations: []string{"*:*:*"}},
+	}}
+	guard := Policy{ID: "guard", Statements: []Statement{
+		{ID: "no-sys-delete", Effect: EffectDeny, Operations: []string{"sys:*:delete"}},
+	}}
+
+	tests := []struct {
+		name     string
+		op       Operation
+		policies []Policy
+		allowed  bool
+		reason   DecisionReason
+	}{
+		{"allow from first policy", "sys:users:create", []Policy{base, guard}, true, ReasonExplicitAllow},
+		{"deny from later policy wins", "sys:users:delete", []Policy{base, guard}, false, ReasonExplicitDeny},
+		{"deny regardless of order", "sys:users:delete", []Policy{guard, base}, false, ReasonExplicitDeny},
+		{"deny only", "sys:users:create", []Policy{guard}, false, ReasonNoMatch},
+		{"no policies", "sys:users:create", nil, false, ReasonNoMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Evaluate(tt.op, "", tt.policies...)
+			if d.Allowed != tt.allowed || d.Reason != tt.reason {
+				t.Fatalf("Evaluate = %+v, want allowed=%v reason=%s", d, tt.allowed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateWithAttributesDenyOverrides(t *testing.T) {
+	p := Policy{Statements: []Statement{
+		{ID: "owner", Effect: EffectAllow, Operations: []string{"org.*:orders:update"}, Condition: `resource.owner == @me`},
+		{ID: "locked", Effect: EffectDeny, Operations: []string{"org.*:orders:*"}, Condition: `resource.locked == true`},
+	}}
+	subject := map[string]any{"id": "7"}
+
+	tests := []struct {
+		name     string
+		resource map[string]any
+		allowed  bool
+		reason   DecisionReason
+	}{
+		{"owner allowed", map[string]any{"owner": "7", "locked": false}, true, ReasonExplicitAllow},
+		{"not owner", map[string]any{"owner": "8", "locked": false}, false, ReasonNoMatch},
+		{"locked overrides owner", map[string]any{"owner": "7", "locked": true}, false, ReasonExplicitDeny},
+		{"unknown lock state fails closed", map[string]any{"owner": "7"}, false, ReasonExplicitDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := Attributes{AttrSubject: subject, AttrResource: tt.resource}
+			d := p.EvaluateWithAttributes("org.acme:orders:update", "", attrs)
+			if d.Allowed != tt.allowed || d.Reason != tt.reason {
+				t.Fatalf("EvaluateWithAttributes = %+v, want allowed=%v reason=%s", d, tt.allowed, tt.reason)
+			}
+		})
+	}
+
+	// 没有属性时：条件 allow 不命中，条件 deny 命中
+	if d := p.Evaluate("org.acme:orders:update", ""); d.Reason != ReasonExplicitDeny {
+		t.Fatalf("Evaluate without attributes = %+v, want explicit deny", d)
+	}
+}
+
+func TestDecisionString(t *testing.T) {
+	s := Statement{ID: "x"}
+	tests := []struct {
+		d    Decision
+		want string
+	}{
+		{Decision{Reason: ReasonNoMatch, Index: -1}, "no_match"},
+		{Decision{Reason: ReasonExplicitDeny, Statement: &s, Index: 2}, `explicit_deny (statement "x")`},
+		{Decision{Reason: ReasonExplicitAllow, Statement: &Statement{}, Index: 3}, "explicit_allow (statement #3)"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package permission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// RiskLevel 操作风险等级，用于管理界面提示与审计。
+type RiskLevel string
+
+const (
+	RiskLow      RiskLevel = "low"      // 只读或低影响操作
+	RiskMedium   RiskLevel = "medium"   // 普通写操作
+	RiskHigh     RiskLevel = "high"     // 删除、权限变更等
+	RiskCritical RiskLevel = "critical" // 不可逆或影响全局的操作
+)
+
+// ErrOperationExists 操作已注册。
+var ErrOperationExists = errors.New("permission: operation already registered")
+
+// OperationInfo 操作的描述信息。
+type OperationInfo struct {
+	Operation   Operation `json:"operation"`             // 操作标识符，如 sys:users:create
+	Name        string    `json:"name"`                  // 显示名称，如 "创建用户"
+	Description string    `json:"description,omitempty"` // 详细说明
+	Group       string    `json:"group,omitempty"`       // 分组，如 "用户管理"
+	Risk        RiskLevel `json:"risk,omitempty"`        // 风险等级
+}
+
+// OperationRegistry 操作目录。
+//
+// 为角色编辑器等管理界面提供全部已声明操作的清单，
+// 并可检查授权模式是否至少匹配一个已注册操作（发现失效授权）。
+//
+// 注册表是并发安全的。包级默认实例为 [DefaultOperations]。
+type OperationRegistry struct {
+	mu  sync.RWMutex
+	ops map[Operation]OperationInfo
+}
+
+// DefaultOperations 默认操作注册表。
+var DefaultOperations = NewOperationRegistry()
+
+// NewOperationRegistry 创建空的操作注册表。
+func NewOperationRegistry() *OperationRegistry {
+	return &OperationRegistry{ops: make(map[Operation]OperationInfo)}
+}
+
+// RegisterOperation 向 [DefaultOperations] 注册操作。
+func RegisterOperation(info OperationInfo) error {
+	return DefaultOperations.Register(info)
+}
+
+// MustRegisterOperation 向 [DefaultOperations] 注册操作并返回操作标识符，失败时 panic。
+//
+// 适用于包级变量声明，让声明即注册：
+//
+//	var UserCreate = permission.MustRegisterOperation(permission.OperationInfo{
+//	    Operation: "sys:users:create",
+//	    Name:      "创建用户",
+//	    Group:     "用户管理",
+//	    Risk:      permission.RiskMedium,
+//	})
+func MustRegisterOperation(info OperationInfo) Operation {
+	if err := DefaultOperations.Register(info); err != nil {
+		panic(err)
+	}
+	return info.Operation
+}
+
+// Register 注册操作。
+//
+// 操作必须通过 [ParseOperation] 严格校验，且不能包含通配符或变量；
+// 重复注册返回 [ErrOperationExists]。Name 为空时使用操作标识符。
+func (r *OperationRegistry) Register(info OperationInfo) error {
+	if _, err := ParseOperation(string(info.Operation)); err != nil {
+		return err
+	}
+	if strings.ContainsAny(string(info.Operation), "*@") {
+		return fmt.Errorf("%w: %q: registered operations must be concrete", ErrInvalidURN, info.Operation)
+	}
+	if info.Name == "" {
+		info.Name = string(info.Operation)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.ops[info.Operation]; exists {
+		return fmt.Errorf("%w: %s", ErrOperationExists, info.Operation)
+	}
+	r.ops[info.Operation] = info
+	return nil
+}
+
+// Lookup 返回操作的描述信息。
+func (r *OperationRegistry) Lookup(op Operation) (OperationInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.ops[op]
+	return info, ok
+}
+
+// Len 返回已注册操作数量。
+func (r *OperationRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.ops)
+}
+
+// List 返回全部已注册操作（按分组、操作标识符排序）。
+func (r *OperationRegistry) List() []OperationInfo {
+	r.mu.RLock()
+	list := make([]OperationInfo, 0, len(r.ops))
+	for _, info := range r.ops {
+		list = append(list, info)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(list, func(a, b OperationInfo) int {
+		if c := strings.Compare(a.Group, b.Group); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Operation), string(b.Operation))
+	})
+	return list
+}
+
+// Match 返回被模式匹配的全部已注册操作（排序同 [OperationRegistry.List]）。
+func (r *OperationRegistry) Match(pattern string) []OperationInfo {
+	var out []OperationInfo
+	for _, info := range r.List() {
+		if MatchOperation(pattern, string(info.Operation)) {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+// Covers 报告模式是否至少匹配一个已注册操作。
+func (r *OperationRegistry) Covers(pattern string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for op := range r.ops {
+		if MatchOperation(pattern, string(op)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeadPatterns 返回不匹配任何已注册操作的模式（失效授权），保持输入顺序。
+//
+// 包含变量的模式需要先使用 [Resolver] 解析，否则会被判定为失效。
+//
+// 示例：
+//
+//	grants, _ := roles.Expand("admin")
+//	if dead := permission.DefaultOperations.DeadPatterns(grants.Operations...); len(dead) > 0 {
+//	    slog.Warn("dead grants", "patterns", dead)
+//	}
+func (r *OperationRegistry) DeadPatterns(patterns ...string) []string {
+	var dead []string
+	for _, p := range patterns {
+		if !r.Covers(p) {
+			dead = append(dead, p)
+		}
+	}
+	return dead
+}
+
+// MarshalJSON 将目录导出为 JSON 数组（排序同 [OperationRegistry.List]）。
+func (r *OperationRegistry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.List())
+}
+
+// WriteJSON 将目录以缩进 JSON 写入 w，便于导出给前端或提交到仓库比对。
+func (r *OperationRegistry) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.List())
+}
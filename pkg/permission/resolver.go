@@ -1,14 +1,37 @@
 package permission
 
 import (
+	"errors"
+	"fmt"
 	"maps"
 	"strings"
 )
 
+// 常用变量名。
+const (
+	VarMe   = "@me"   // 当前用户 ID
+	VarOrg  = "@org"  // 当前组织 ID
+	VarTeam = "@team" // 当前团队 ID
+)
+
+// 变量解析错误。
+var (
+	// ErrUnresolvedVariable URN 中存在未提供取值的变量（以 @ 开头的 token）。
+	ErrUnresolvedVariable = errors.New("permission: unresolved variable")
+	// ErrUnsafeValue 变量取值为空或包含 URN 分隔符/通配符（: . *），拒绝替换以防注入。
+	ErrUnsafeValue = errors.New("permission: unsafe variable value")
+)
+
 // Resolver 执行 URN 中的变量替换。
 //
-// 变量是任意字符串，会被替换为对应的值。
-// 常用约定使用 @ 前缀（如 @me, @org），但任何字符串都可以。
+// 替换按 token 进行：URN 先按段分隔符 ":" 与 scope 层级分隔符 "." 切分，
+// 只有与变量名完全相同的 token 才会被替换，且每个 token 只替换一次。
+// 因此：
+//   - @org 与 @organization 互不影响
+//   - 取值中即使包含 @team 也不会被再次替换
+//   - 结果与 map 遍历顺序无关
+//
+// 变量名通常使用 @ 前缀（如 @me, @org）。
 type Resolver struct {
 	vars map[string]string
 }
@@ -47,27 +70,43 @@ func (r *Resolver) ResolveResource(res Resource) Resource {
 }
 
 // ResolveString 替换字符串中的所有变量。
+//
+// 宽松模式：取值不安全的变量与未知变量保持原样（原样的 @xxx 不会匹配任何真实 ID）。
+// 需要感知错误时使用 [Resolver.ResolveStrict]。
 func (r *Resolver) ResolveString(s string) string {
 	if r == nil || len(r.vars) == 0 {
 		return s
 	}
-	for k, v := range r.vars {
-		s = strings.ReplaceAll(s, k, v)
-	}
-	return s
+	out, _, _ := r.resolve(s)
+	return out
 }
 
-// ContainsVar 报告字符串是否包含解析器中的任何变量。
+// ResolveStrict 替换字符串中的所有变量，遇到以下情况返回错误：
+//   - 以 @ 开头但未提供取值的 token（[ErrUnresolvedVariable]）
+//   - 取值为空或包含 : . * 的变量（[ErrUnsafeValue]）
+//
+// 示例：
+//
+//	r := NewResolver(map[string]string{"@me": "123"})
+//	r.ResolveStrict("self:user:@me")  // "self:user:123", nil
+//	r.ResolveStrict("org.@org:*:*")   // ErrUnresolvedVariable
+func (r *Resolver) ResolveStrict(s string) (string, error) {
+	out, _, err := r.resolve(s)
+	return out, err
+}
+
+// ContainsVar 报告字符串是否包含解析器中的任何变量（按 token 判断）。
 func (r *Resolver) ContainsVar(s string) bool {
 	if r == nil || len(r.vars) == 0 {
 		return false
 	}
-	for k := range r.vars {
-		if strings.Contains(s, k) {
-			return true
+	found := false
+	forEachToken(s, func(tok string) {
+		if _, ok := r.vars[tok]; ok {
+			found = true
 		}
-	}
-	return false
+	})
+	return found
 }
 
 // Vars 返回变量映射的副本。
@@ -79,3 +118,61 @@ func (r *Resolver) Vars() map[string]string {
 	maps.Copy(m, r.vars)
 	return m
 }
+
+// resolve 按 token 替换变量，返回结果、发生的替换与第一个错误。
+func (r *Resolver) resolve(s string) (string, []Substitution, error) {
+	var (
+		b    strings.Builder
+		subs []Substitution
+		err  error
+	)
+	b.Grow(len(s))
+
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && s[i] != ':' && s[i] != '.' {
+			continue
+		}
+		tok := s[start:i]
+		out, sub, tokErr := r.token(tok)
+		if sub != nil {
+			subs = append(subs, *sub)
+		}
+		if err == nil {
+			err = tokErr
+		}
+		b.WriteString(out)
+		if i < len(s) {
+			b.WriteByte(s[i])
+		}
+		start = i + 1
+	}
+	return b.String(), subs, err
+}
+
+// token 解析单个 token。
+func (r *Resolver) token(tok string) (string, *Substitution, error) {
+	if r != nil {
+		if v, ok := r.vars[tok]; ok {
+			if v == "" || strings.ContainsAny(v, ":.*") {
+				return tok, nil, fmt.Errorf("%w: %s", ErrUnsafeValue, tok)
+			}
+			return v, &Substitution{Variable: tok, Value: v}, nil
+		}
+	}
+	if len(tok) > 1 && tok[0] == '@' {
+		return tok, nil, fmt.Errorf("%w: %s", ErrUnresolvedVariable, tok)
+	}
+	return tok, nil, nil
+}
+
+// forEachToken 按 ":" 与 "." 切分 URN 并依次回调每个 token。
+func forEachToken(s string, fn func(tok string)) {
+	start := 0
+	for i := 0; i <= len(s); i++ {
+		if i == len(s) || s[i] == ':' || s[i] == '.' {
+			fn(s[start:i])
+			start = i + 1
+		}
+	}
+}
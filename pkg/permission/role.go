@@ -0,0 +1,259 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// 角色相关错误。
+var (
+	// ErrRoleNotFound 角色未注册。
+	ErrRoleNotFound = errors.New("permission: role not found")
+	// ErrRoleCycle 角色继承关系存在环。
+	ErrRoleCycle = errors.New("permission: role inheritance cycle")
+	// ErrRoleInvalid 角色定义无效（如名称为空）。
+	ErrRoleInvalid = errors.New("permission: invalid role")
+)
+
+// Role 角色定义。
+//
+// 角色声明一组操作模式与资源模式，并可继承其他角色的全部模式。
+//
+// Condition 为可选的条件表达式（语法见 [Condition]），设置后角色只在条件对请求属性
+// 求值为 true 时生效（含其继承的角色），见 [RoleRegistry.ExpandWithAttributes]。
+//
+// 示例：
+//
+//	viewer := permission.Role{Name: "viewer", Operations: []string{"sys:*:read"}}
+//	editor := permission.Role{Name: "editor", Inherits: []string{"viewer"}, Operations: []string{"sys:*:update"}}
+//	owner := permission.Role{Name: "order-owner", Operations: []string{"org.*:orders:update"},
+//	    Condition: `resource.owner == @me && resource.amount < 1000`}
+type Role struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Operations  []string `json:"operations,omitempty" yaml:"operations,omitempty"`
+	Resources   []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Inherits    []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+	Condition   string   `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// Grants 角色展开后的授权模式（已去重，保持声明顺序）。
+type Grants struct {
+	Roles      []string // 参与展开的全部角色（含继承）
+	Operations []string // 操作模式
+	Resources  []string // 资源模式
+}
+
+// PermissionSet 将操作模式编译为 [PermissionSet]。
+func (g Grants) PermissionSet() *PermissionSet {
+	return NewPermissionSet(g.Operations...)
+}
+
+// RoleRegistry 角色注册表。
+//
+// 注册表是并发安全的。继承关系在 [RoleRegistry.Register] 时检测环，
+// 被继承的角色允许稍后注册，未注册的角色在展开时报错。
+type RoleRegistry struct {
+	mu    sync.RWMutex
+	roles map[string]Role
+}
+
+// NewRoleRegistry 创建角色注册表，并注册给定的角色。
+func NewRoleRegistry(roles ...Role) (*RoleRegistry, error) {
+	r := &RoleRegistry{roles: make(map[string]Role, len(roles))}
+	for _, role := range roles {
+		if err := r.Register(role); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register 注册（或覆盖）角色。
+//
+// 如果新角色导致继承关系成环，返回 [ErrRoleCycle] 且注册表保持不变。
+func (r *RoleRegistry) Register(role Role) error {
+	if strings.TrimSpace(role.Name) == "" {
+		return fmt.Errorf("%w: empty name", ErrRoleInvalid)
+	}
+	role = cloneRole(role)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, existed := r.roles[role.Name]
+	r.roles[role.Name] = role
+	if path := r.findCycle(role.Name); path != nil {
+		if existed {
+			r.roles[role.Name] = old
+		} else {
+			delete(r.roles, role.Name)
+		}
+		return fmt.Errorf("%w: %s", ErrRoleCycle, strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// Get 返回角色定义的副本。
+func (r *RoleRegistry) Get(name string) (Role, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	role, ok := r.roles[name]
+	if !ok {
+		return Role{}, false
+	}
+	return cloneRole(role), true
+}
+
+// Names 返回所有已注册的角色名（按字母排序）。
+func (r *RoleRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.roles))
+	for name := range r.roles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Expand 展开用户拥有的角色，返回去重后的授权模式。
+//
+// 继承按深度优先展开：角色自身的模式在前，被继承角色的模式在后。
+// 任一角色（含被继承角色）未注册时返回 [ErrRoleNotFound]。
+// 带条件的角色在没有属性时不生效，需要条件时使用 [RoleRegistry.ExpandWithAttributes]。
+//
+// 示例：
+//
+//	roles, _ := ctxutil.Get[[]string](c, ctxutil.Roles)
+//	grants, err := registry.Expand(roles...)
+//	if err == nil && grants.PermissionSet().Allows(op) {
+//	    // 有权限
+//	}
+func (r *RoleRegistry) Expand(names ...string) (Grants, error) {
+	return r.ExpandWithAttributes(nil, names...)
+}
+
+// ExpandWithAttributes 同 [RoleRegistry.Expand]，带条件的角色使用 attrs 求值。
+//
+// 条件为 false 或因属性缺失、表达式无效而无法确定时，该角色及其继承的角色
+// （除非经由其他生效的角色继承）都不参与展开，也不出现在 Grants.Roles 中。
+func (r *RoleRegistry) ExpandWithAttributes(attrs Attributes, names ...string) (Grants, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e := expander{
+		attrs:    attrs,
+		registry: r,
+		visited:  make(map[string]bool),
+		ops:      make(map[string]bool),
+		res:      make(map[string]bool),
+	}
+	for _, name := range names {
+		if err := e.expand(name); err != nil {
+			return Grants{}, err
+		}
+	}
+	return e.grants, nil
+}
+
+// findCycle 从 start 出发查找继承环，返回环路径；无环返回 nil。
+// 调用方需持有锁。
+func (r *RoleRegistry) findCycle(start string) []string {
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			i := slices.Index(path, name)
+			return append(slices.Clone(path[i:]), name)
+		case done:
+			return nil
+		}
+		role, ok := r.roles[name]
+		if !ok {
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, parent := range role.Inherits {
+			if cycle := visit(parent); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+	return visit(start)
+}
+
+// expander 执行角色展开，跟踪已访问角色与已收集模式。
+type expander struct {
+	attrs    Attributes
+	registry *RoleRegistry
+	visited  map[string]bool
+	ops      map[string]bool
+	res      map[string]bool
+	grants   Grants
+}
+
+func (e *expander) expand(name string) error {
+	if e.visited[name] {
+		return nil
+	}
+	role, ok := e.registry.roles[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrRoleNotFound, name)
+	}
+	e.visited[name] = true
+	if !roleApplies(role, e.attrs) {
+		return nil
+	}
+	e.grants.Roles = append(e.grants.Roles, name)
+
+	for _, p := range role.Operations {
+		if !e.ops[p] {
+			e.ops[p] = true
+			e.grants.Operations = append(e.grants.Operations, p)
+		}
+	}
+	for _, p := range role.Resources {
+		if !e.res[p] {
+			e.res[p] = true
+			e.grants.Resources = append(e.grants.Resources, p)
+		}
+	}
+	for _, parent := range role.Inherits {
+		if err := e.expand(parent); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// roleApplies 报告角色条件对 attrs 是否求值为 true，无条件的角色总是生效。
+func roleApplies(role Role, attrs Attributes) bool {
+	if role.Condition == "" {
+		return true
+	}
+	cond, err := compileCached(role.Condition)
+	return err == nil && cond.Eval(attrs)
+}
+
+// cloneRole 深拷贝角色，防止外部修改注册表内部状态。
+func cloneRole(r Role) Role {
+	r.Operations = slices.Clone(r.Operations)
+	r.Resources = slices.Clone(r.Resources)
+	r.Inherits = slices.Clone(r.Inherits)
+	return r
+}
@@ -0,0 +1,244 @@
+package permission
+
+import "strings"
+
+// PermissionSet 预编译的操作模式集合。
+//
+// 适用于授权模式较多（数百条）的场景：模式在构造时一次性解析为
+// scope → type → identifier 三级前缀树，匹配时只需沿目标 URN 的
+// scope 层级向下查找，耗时与模式数量无关。
+//
+// 匹配语义与逐条调用 [MatchOperation] 完全一致：
+//   - * 匹配该段任意值
+//   - sys.* 匹配 sys 及其子 scope
+//
+// PermissionSet 构造后只读，可安全地被多个 goroutine 并发使用。
+//
+// 示例：
+//
+//	set := permission.NewPermissionSet("sys:users:*", "org.*:*:read")
+//	set.Allows("sys:users:create")     // true
+//	set.Allows("org.acme:orders:read") // true
+//	set.Allows("sys:roles:create")     // false
+type PermissionSet struct {
+	root     scopeNode
+	all      bool
+	patterns []string
+}
+
+// NewPermissionSet 编译操作模式集合。
+//
+// 模式中的变量（如 @me）需要事先使用 [Resolver] 解析。
+func NewPermissionSet(patterns ...string) *PermissionSet {
+	s := &PermissionSet{patterns: make([]string, 0, len(patterns))}
+	seen := make(map[string]struct{}, len(patterns))
+	for _, p := range patterns {
+		if _, dup := seen[p]; dup {
+			continue
+		}
+		seen[p] = struct{}{}
+		s.patterns = append(s.patterns, p)
+		s.insert(p)
+	}
+	return s
+}
+
+// Allows 报告集合中是否有模式匹配该操作。
+func (s *PermissionSet) Allows(op Operation) bool {
+	return s.Match(string(op))
+}
+
+// AllowsResource 报告集合中是否有模式匹配该资源。
+func (s *PermissionSet) AllowsResource(res Resource) bool {
+	return s.Match(string(res))
+}
+
+// Match 报告集合中是否有模式匹配目标 URN。
+func (s *PermissionSet) Match(target string) bool {
+	if s == nil {
+		return false
+	}
+	if s.all {
+		return true
+	}
+
+	scope, typ, id := splitURN(target)
+
+	n := &s.root
+	if n.subtree.match(typ, id) {
+		return true
+	}
+	for {
+		part, rest, more := strings.Cut(scope, ".")
+		if n = n.children[part]; n == nil {
+			return false
+		}
+		if n.subtree.match(typ, id) {
+			return true
+		}
+		if !more {
+			break
+		}
+		scope = rest
+	}
+	return n.exact.match(typ, id)
+}
+
+// Patterns 返回集合中的模式（去重后，保持原始顺序）。
+func (s *PermissionSet) Patterns() []string {
+	if s == nil {
+		return nil
+	}
+	out := make([]string, len(s.patterns))
+	copy(out, s.patterns)
+	return out
+}
+
+// Len 返回集合中的模式数量（去重后）。
+func (s *PermissionSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.patterns)
+}
+
+// insert 将模式编译进前缀树。
+func (s *PermissionSet) insert(pattern string) {
+	if pattern == "*" || pattern == "*:*:*" {
+		s.all = true
+		return
+	}
+
+	p := parseURN(pattern)
+
+	// scope 为 * 等价于根节点的子树通配
+	if p.Scope == "*" {
+		s.root.subtreeNode().add(p.Type, p.Identifier)
+		return
+	}
+
+	prefix, hierarchical := strings.CutSuffix(p.Scope, ".*")
+	n := &s.root
+	for part := range strings.SplitSeq(prefix, ".") {
+		n = n.child(part)
+	}
+	if hierarchical {
+		n.subtreeNode().add(p.Type, p.Identifier)
+	} else {
+		n.exactNode().add(p.Type, p.Identifier)
+	}
+}
+
+// ============================================================================
+// 前缀树节点
+// ============================================================================
+
+// scopeNode scope 层级节点。
+type scopeNode struct {
+	children map[string]*scopeNode
+	exact    *typeNode // scope 恰好止于本节点的模式（如 sys）
+	subtree  *typeNode // 匹配本节点及所有子 scope 的模式（如 sys.*）
+}
+
+func (n *scopeNode) child(part string) *scopeNode {
+	if n.children == nil {
+		n.children = make(map[string]*scopeNode)
+	}
+	c, ok := n.children[part]
+	if !ok {
+		c = &scopeNode{}
+		n.children[part] = c
+	}
+	return c
+}
+
+func (n *scopeNode) exactNode() *typeNode {
+	if n.exact == nil {
+		n.exact = &typeNode{}
+	}
+	return n.exact
+}
+
+func (n *scopeNode) subtreeNode() *typeNode {
+	if n.subtree == nil {
+		n.subtree = &typeNode{}
+	}
+	return n.subtree
+}
+
+// typeNode type 段节点。
+type typeNode struct {
+	types map[string]*idNode
+	any   *idNode // type 为 * 的模式
+}
+
+func (n *typeNode) add(typ, id string) {
+	var leaf *idNode
+	if typ == "*" {
+		if n.any == nil {
+			n.any = &idNode{}
+		}
+		leaf = n.any
+	} else {
+		if n.types == nil {
+			n.types = make(map[string]*idNode)
+		}
+		leaf = n.types[typ]
+		if leaf == nil {
+			leaf = &idNode{}
+			n.types[typ] = leaf
+		}
+	}
+	leaf.add(id)
+}
+
+func (n *typeNode) match(typ, id string) bool {
+	if n == nil {
+		return false
+	}
+	return n.any.match(id) || n.types[typ].match(id)
+}
+
+// idNode identifier 段节点。
+type idNode struct {
+	ids map[string]struct{}
+	any bool // identifier 为 * 的模式
+}
+
+func (n *idNode) add(id string) {
+	if id == "*" {
+		n.any = true
+		return
+	}
+	if n.ids == nil {
+		n.ids = make(map[string]struct{})
+	}
+	n.ids[id] = struct{}{}
+}
+
+func (n *idNode) match(id string) bool {
+	if n == nil {
+		return false
+	}
+	if n.any {
+		return true
+	}
+	_, ok := n.ids[id]
+	return ok
+}
+
+// splitURN 按 [parseURN] 的规则拆分 URN，但不分配内存。
+func splitURN(s string) (scope, typ, id string) {
+	if s == "*" {
+		return "*", "*", "*"
+	}
+	scope, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return scope, "*", "*"
+	}
+	typ, id, ok = strings.Cut(rest, ":")
+	if !ok {
+		return scope, typ, "*"
+	}
+	return scope, typ, id
+}
@@ -0,0 +1,100 @@
+package permission
+
+import (
+	"fmt"
+	"testing"
+)
+
+// grantCounts 基准测试使用的授权模式数量，覆盖普通用户到超级管理员的典型规模。
+var grantCounts = []int{10, 100, 1000}
+
+// benchGrants 生成 n 条授权模式，混合精确模式、通配模式与子 scope 模式。
+func benchGrants(n int) []string {
+	grants := make([]string, 0, n)
+	for i := range n {
+		switch i % 4 {
+		case 0:
+			grants = append(grants, fmt.Sprintf("org.t%d:orders:read", i))
+		case 1:
+			grants = append(grants, fmt.Sprintf("org.t%d:invoices:*", i))
+		case 2:
+			grants = append(grants, fmt.Sprintf("sys.m%d.*:users:update", i))
+		default:
+			grants = append(grants, fmt.Sprintf("self:profile%d:*", i))
+		}
+	}
+	return grants
+}
+
+// benchTargets 返回命中最后一条模式的操作与未命中任何模式的操作。
+func benchTargets(n int) (hit, miss Operation) {
+	return Operation(fmt.Sprintf("org.t%d:orders:read", (n-1)/4*4)), "org.other:orders:delete"
+}
+
+// linearAllows 逐条调用 [MatchOperation]，作为 [PermissionSet] 的对照。
+func linearAllows(grants []string, op Operation) bool {
+	for _, p := range grants {
+		if MatchOperation(p, string(op)) {
+			return true
+		}
+	}
+	return false
+}
+
+func BenchmarkPermissionSetBuild(b *testing.B) {
+	for _, n := range grantCounts {
+		grants := benchGrants(n)
+		b.Run(fmt.Sprintf("grants=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				NewPermissionSet(grants...)
+			}
+		})
+	}
+}
+
+func BenchmarkPermissionSetAllows(b *testing.B) {
+	for _, n := range grantCounts {
+		set := NewPermissionSet(benchGrants(n)...)
+		hit, miss := benchTargets(n)
+		b.Run(fmt.Sprintf("grants=%d/hit", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if !set.Allows(hit) {
+					b.Fatal("expected hit")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("grants=%d/miss", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if set.Allows(miss) {
+					b.Fatal("expected miss")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLinearAllows(b *testing.B) {
+	for _, n := range grantCounts {
+		grants := benchGrants(n)
+		hit, miss := benchTargets(n)
+		b.Run(fmt.Sprintf("grants=%d/hit", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if !linearAllows(grants, hit) {
+					b.Fatal("expected hit")
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("grants=%d/miss", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if linearAllows(grants, miss) {
+					b.Fatal("expected miss")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package permission
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot 策略存储的一个版本。
+//
+// 同一请求内应始终使用同一个 Snapshot，避免热加载导致前后判断不一致。
+// Snapshot 被所有请求并发共享，调用方只能读取：Document 与 Roles 不得修改
+// （如调用 Roles.Register），需要调整策略时修改配置文件并重新加载。
+type Snapshot struct {
+	Version  uint64        // 版本号，从 1 开始，每次成功加载递增
+	ETag     string        // 内容摘要（sha256 前 16 字节十六进制），内容不变则不变
+	LoadedAt time.Time     // 加载时间
+	Document *Document     // 原始配置
+	Roles    *RoleRegistry // 由 Document.Roles 构造的角色注册表
+}
+
+// Evaluate 使用快照中的全部策略评估操作与资源。
+func (s *Snapshot) Evaluate(op Operation, res Resource) Decision {
+	return s.EvaluateWithAttributes(op, res, nil)
+}
+
+// EvaluateWithAttributes 同 [Snapshot.Evaluate]，条件语句使用 attrs 求值。
+func (s *Snapshot) EvaluateWithAttributes(op Operation, res Resource, attrs Attributes) Decision {
+	if s == nil || s.Document == nil {
+		return Decision{Reason: ReasonNoMatch, Index: -1}
+	}
+	return EvaluateWithAttributes(op, res, attrs, s.Document.Policies...)
+}
+
+// Grants 展开用户角色为授权模式。
+//
+// 与 [RoleRegistry.Expand] 不同，快照中不存在的角色会被忽略，
+// 避免配置中删除某个角色后持有该角色的用户完全无法展开其他角色。
+func (s *Snapshot) Grants(roles ...string) Grants {
+	return s.GrantsWithAttributes(nil, roles...)
+}
+
+// GrantsWithAttributes 同 [Snapshot.Grants]，带条件的角色使用 attrs 求值。
+func (s *Snapshot) GrantsWithAttributes(attrs Attributes, roles ...string) Grants {
+	if s == nil || s.Roles == nil {
+		return Grants{}
+	}
+	known := make([]string, 0, len(roles))
+	for _, name := range roles {
+		if _, ok := s.Roles.Get(name); ok {
+			known = append(known, name)
+		}
+	}
+	g, _ := s.Roles.ExpandWithAttributes(attrs, known...)
+	return g
+}
+
+// StoreOption 配置 [PolicyStore]。
+type StoreOption func(*PolicyStore)
+
+// WithPollInterval 设置 [PolicyStore.Watch] 的轮询间隔，默认 5 秒。
+func WithPollInterval(d time.Duration) StoreOption {
+	return func(s *PolicyStore) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithReloadErrorHandler 设置热加载失败时的回调（如记录日志）。
+func WithReloadErrorHandler(fn func(error)) StoreOption {
+	return func(s *PolicyStore) { s.onError = fn }
+}
+
+// PolicyStore 可热加载的策略存储。
+//
+// path 可以是单个配置文件，也可以是目录（加载目录下所有 .yaml、.yml、.json 文件，
+// 按文件名排序后合并）。
+//
+// 特性：
+//   - 当前版本保存在 atomic.Pointer 中，读取无锁
+//   - 加载失败时继续使用上一个成功的版本，错误可通过 [PolicyStore.LastError] 获取
+//   - 内容变化时通知订阅者（见 [PolicyStore.Subscribe]）
+//
+// 使用示例：
+//
+//	store, err := permission.NewPolicyStore("configs/permissions")
+//	if err != nil {
+//	    return err
+//	}
+//	go store.Watch(ctx)
+//
+//	snap := store.Snapshot()
+//	snap.Evaluate("sys:users:delete", "")
+type PolicyStore struct {
+	path     string
+	interval time.Duration
+	onError  func(error)
+
+	current atomic.Pointer[Snapshot]
+
+	mu      sync.Mutex // 保护以下字段，并串行化加载
+	stamp   string     // 上次检查时的文件元信息签名
+	lastErr error
+	subs    map[int]func(*Snapshot)
+	nextSub int
+
+	notifyMu sync.Mutex // 串行化订阅者通知，不持有 mu 以便回调访问 PolicyStore
+	notified uint64     // 已通知的最新版本号
+}
+
+// NewPolicyStore 创建策略存储并执行首次加载。
+//
+// 首次加载失败时返回错误。
+func NewPolicyStore(path string, opts ...StoreOption) (*PolicyStore, error) {
+	s := &PolicyStore{
+		path:     path,
+		interval: 5 * time.Second,
+		subs:     make(map[int]func(*Snapshot)),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Snapshot 返回当前版本。
+func (s *PolicyStore) Snapshot() *Snapshot {
+	return s.current.Load()
+}
+
+// Version 返回当前版本号。
+func (s *PolicyStore) Version() uint64 {
+	if snap := s.current.Load(); snap != nil {
+		return snap.Version
+	}
+	return 0
+}
+
+// ETag 返回当前版本的内容摘要。
+func (s *PolicyStore) ETag() string {
+	if snap := s.current.Load(); snap != nil {
+		return snap.ETag
+	}
+	return ""
+}
+
+// LastError 返回最近一次加载的错误，成功时为 nil。
+func (s *PolicyStore) LastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastErr
+}
+
+// Subscribe 注册版本变更回调，返回取消订阅函数。
+//
+// 回调在加载它的 goroutine 中同步执行，不应阻塞。
+// 多个 goroutine 并发加载时回调依次执行，且版本号严格递增：较旧的版本在较新的版本之后到达时被跳过。
+func (s *PolicyStore) Subscribe(fn func(*Snapshot)) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextSub
+	s.nextSub++
+	s.subs[id] = fn
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+// Reload 立即重新加载配置。
+//
+// 内容未变化（ETag 相同）时不产生新版本；加载失败时保留当前版本并返回错误。
+func (s *PolicyStore) Reload() error {
+	s.mu.Lock()
+	snap, subs, err := s.reloadLocked()
+	s.mu.Unlock()
+
+	if err != nil {
+		if s.onError != nil {
+			s.onError(err)
+		}
+		return err
+	}
+	if len(subs) > 0 {
+		s.notify(snap, subs)
+	}
+	return nil
+}
+
+// notify 按版本顺序通知订阅者，跳过已被更新版本取代的快照。
+func (s *PolicyStore) notify(snap *Snapshot, subs []func(*Snapshot)) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+	if snap.Version <= s.notified {
+		return
+	}
+	s.notified = snap.Version
+	for _, fn := range subs {
+		fn(snap)
+	}
+}
+
+// Watch 按轮询间隔检查配置文件变化并自动加载，直到 ctx 取消。
+//
+// 仅当文件的修改时间或大小变化时才重新解析。
+func (s *PolicyStore) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stamp, err := statPath(s.path)
+			s.mu.Lock()
+			changed := err != nil || stamp != s.stamp
+			s.mu.Unlock()
+			if changed {
+				_ = s.Reload()
+			}
+		}
+	}
+}
+
+// reloadLocked 加载配置并在内容变化时交换版本，返回需要通知的订阅者。
+// 调用方需持有 s.mu。
+func (s *PolicyStore) reloadLocked() (*Snapshot, []func(*Snapshot), error) {
+	stamp, _ := statPath(s.path)
+	s.stamp = stamp
+
+	doc, etag, err := loadPath(s.path)
+	if err != nil {
+		s.lastErr = err
+		return nil, nil, err
+	}
+	roles, err := doc.RoleRegistry()
+	if err != nil {
+		s.lastErr = err
+		return nil, nil, err
+	}
+	s.lastErr = nil
+
+	prev := s.current.Load()
+	if prev != nil && prev.ETag == etag {
+		return prev, nil, nil
+	}
+
+	snap := &Snapshot{
+		Version:  1,
+		ETag:     etag,
+		LoadedAt: time.Now(),
+		Document: doc,
+		Roles:    roles,
+	}
+	if prev != nil {
+		snap.Version = prev.Version + 1
+	}
+	s.current.Store(snap)
+
+	subs := make([]func(*Snapshot), 0, len(s.subs))
+	for _, fn := range s.subs {
+		subs = append(subs, fn)
+	}
+	return snap, subs, nil
+}
+
+// ============================================================================
+// 文件读取
+// ============================================================================
+
+// policyFiles 返回 path 对应的配置文件列表（目录时按文件名排序）。
+func policyFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !isPolicyFile(e.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(path, e.Name()))
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// isPolicyFile 报告文件名是否为支持的配置格式。
+func isPolicyFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// loadPath 加载并合并 path 下的全部配置，返回合并后的文档与内容摘要。
+func loadPath(path string) (*Document, string, error) {
+	files, err := policyFiles(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("permission: no policy files in %s", path)
+	}
+
+	h := sha256.New()
+	merged := &Document{}
+	var errs []error
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		h.Write([]byte(f))
+		h.Write(data)
+
+		doc, err := load(f, data, false)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		merged.Roles = append(merged.Roles, doc.Roles...)
+		merged.Policies = append(merged.Policies, doc.Policies...)
+	}
+	if len(errs) > 0 {
+		return nil, "", errors.Join(errs...)
+	}
+	if err := checkRoles(merged); err != nil {
+		return nil, "", err
+	}
+	return merged, hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// statPath 返回配置文件元信息（名称、大小、修改时间）的签名，用于廉价的变更检测。
+func statPath(path string) (string, error) {
+	files, err := policyFiles(path)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s|%d|%d;", f, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
@@ -39,6 +39,16 @@ func Forbidden(c *gin.Context, message ...string) {
 	Failure(c, http.StatusForbidden, msg)
 }
 
+// ForbiddenWithDetails 403 无权限（带详情）
+// 用于在调试场景下附带权限判定说明等额外信息
+func ForbiddenWithDetails(c *gin.Context, details any, message ...string) {
+	msg := MsgAccessForbidden
+	if len(message) > 0 && message[0] != "" {
+		msg = message[0]
+	}
+	Failure(c, http.StatusForbidden, msg, details)
+}
+
 // NotFound 404 资源不存在
 func NotFound(c *gin.Context, resource string) {
 	message := MsgResourceNotFound
@@ -84,4 +84,7 @@ const (
 
 	// MsgServiceUnavailable 表示服务暂时不可用
 	MsgServiceUnavailable = "服务暂时不可用"
+
+	// MsgGatewayTimeout 表示请求处理超时
+	MsgGatewayTimeout = "请求处理超时"
 )
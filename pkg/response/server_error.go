@@ -32,3 +32,12 @@ func ServiceUnavailable(c *gin.Context, message ...string) {
 	}
 	Failure(c, http.StatusServiceUnavailable, msg)
 }
+
+// GatewayTimeout 504 处理超时
+func GatewayTimeout(c *gin.Context, message ...string) {
+	msg := MsgGatewayTimeout
+	if len(message) > 0 && message[0] != "" {
+		msg = message[0]
+	}
+	Failure(c, http.StatusGatewayTimeout, msg)
+}
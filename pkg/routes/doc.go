@@ -1 +1,46 @@
+// Package routes 提供声明式路由定义与注册。
+//
+// 路由以 [Route] 切片声明，通过 [Register] 统一安装：
+//
+//	var userRoutes = []routes.Route{
+//	    {Method: routes.GET, Path: "/users", Operation: "sys:users:list", Handler: listUsers},
+//	    {Method: routes.POST, Path: "/users", Operation: "sys:users:create", Handler: createUser},
+//	}
+//
+//	if err := routes.Register(r.Group("/api"), userRoutes,
+//	    routes.WithMiddlewares(middleware.RequirePermission()),
+//	); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// Register 会为每个路由注入 middleware.SetOperationID，
+// 并在安装前拒绝重复的方法 + 路径与重复的 Operation。
+//
+// # 类型化处理函数
+//
+// [Handle] 负责绑定、校验与统一响应，处理函数只需关注业务逻辑：
+//
+//	func createUser(ctx context.Context, req CreateUserReq) (UserDTO, error) { ... }
+//
+//	{Method: routes.POST, Path: "/users", Handler: routes.Handle(createUser)}
+//
+// # 版本控制
+//
+// [WithVersioning] 让一张路由表以 Route.Version 区分多个 API 版本，
+// 支持路径前缀、Accept-Version 请求头与媒体类型三种方式，未提供的版本回退到最近的较低版本；
+// 标记 Deprecated 或 Sunset 的路由自动附带 Deprecation、Sunset 响应头。
+//
+// # OpenAPI
+//
+// 路由的文档字段（Tags、Summary、Request、Response 等）可直接生成 OpenAPI 3.1 文档，
+// 无需维护 swag 注释：
+//
+//	doc := routes.NewOpenAPI(routes.OpenAPIInfo{Title: "Admin API", Version: "1.0.0"})
+//	routes.MustRegister(r.Group("/api"), userRoutes, routes.WithOpenAPI(doc))
+//	r.GET("/openapi.json", doc.Handler())
+//
+// # 路由清单
+//
+// [WithInventory] 记录实际注册的路由（完整路径、Operation、中间件等），
+// 配合 handler.Routes 与 handler.PrintRoutes 输出。
 package routes
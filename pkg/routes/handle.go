@@ -0,0 +1,407 @@
+package routes
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// ============================================================================
+// 类型化处理函数
+// ============================================================================
+
+// HandlerFunc 类型化处理函数。
+//
+// 不依赖 *gin.Context，单元测试时直接传入 context.Background() 与请求结构体即可。
+type HandlerFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// Handle 将类型化处理函数适配为 gin.HandlerFunc。
+//
+// 请求处理流程：
+//  1. 绑定查询参数（form 标签）、请求头（header 标签）、路径参数（uri 标签），后者覆盖前者
+//  2. 绑定请求体（POST/PUT/PATCH，仅支持 JSON，其他类型返回 415），覆盖 form 标签的 default= 默认值
+//  3. 按 binding 标签统一校验一次，失败返回 400 与字段错误列表
+//  4. 调用 fn，错误按 [MapError] 与内置规则转换为对应的错误响应
+//  5. 成功时写入 response.DataResponse[Resp]（处理函数已自行写入响应时跳过）
+//
+// 查询参数只绑定到显式声明了 form 标签的字段，不会覆盖请求体中的同名字段。
+//
+// 传入 fn 的 ctx 派生自 c.Request.Context()，携带 Timeout 等中间件设置的截止时间与客户端断开引起的取消，
+// 并包含调用时 Gin context 中的值，可通过 ctx.Value(ctxutil.UserID) 读取；
+// 需要 *gin.Context 时使用 ctx.Value(gin.ContextKey)。
+//
+// Req 必须是结构体类型，否则在注册时 panic。
+//
+// 示例：
+//
+//	type GetUserReq struct {
+//	    ID int64 `uri:"id" binding:"required"`
+//	}
+//
+//	func getUser(ctx context.Context, req GetUserReq) (UserDTO, error) {
+//	    u, err := repo.Find(ctx, req.ID)
+//	    if err != nil {
+//	        return UserDTO{}, fmt.Errorf("%w: 用户 %d", routes.ErrNotFound, req.ID)
+//	    }
+//	    return toDTO(u), nil
+//	}
+//
+//	{Method: routes.GET, Path: "/users/:id", Handler: routes.Handle(getUser),
+//	    Request: GetUserReq{}, Response: UserDTO{}}
+func Handle[Req, Resp any](fn HandlerFunc[Req, Resp]) gin.HandlerFunc {
+	t := reflect.TypeFor[Req]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("routes: Handle: request type %s must be a struct", t))
+	}
+	tags := bindTags{
+		form:   tagNames(t, "form"),
+		header: tagNames(t, "header"),
+		uri:    tagNames(t, "uri"),
+	}
+
+	return func(c *gin.Context) {
+		var req Req
+		if err := bind(c, &req, tags); err != nil {
+			writeError(c, err)
+			return
+		}
+
+		resp, err := fn(handlerContext(c), req)
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+		if c.Writer.Written() {
+			return
+		}
+		c.JSON(http.StatusOK, response.DataResponse[Resp]{
+			Code:    http.StatusOK,
+			Message: response.MsgSuccess,
+			Data:    resp,
+		})
+	}
+}
+
+// handlerContext 返回传给类型化处理函数的 context：c.Request.Context() 加上 Gin context 值的快照。
+func handlerContext(c *gin.Context) context.Context {
+	return requestContext{Context: c.Request.Context(), c: c, keys: maps.Clone(c.Keys)}
+}
+
+// requestContext 优先从 Gin context 值的快照中查找，其余交给请求 context。
+type requestContext struct {
+	context.Context
+	c    *gin.Context
+	keys map[any]any
+}
+
+func (ctx requestContext) Value(key any) any {
+	if key == gin.ContextKey {
+		return ctx.c
+	}
+	if v, ok := ctx.keys[key]; ok {
+		return v
+	}
+	return ctx.Context.Value(key)
+}
+
+// bindTags 请求结构体中显式声明的各来源参数名。
+type bindTags struct {
+	form, header, uri []string
+}
+
+// bind 绑定并校验请求。
+//
+// 每个来源只传入声明过的参数名：gin 会把未声明标签的字段按字段名匹配，
+// 否则 ?Name=x 之类的查询参数可以覆盖请求体中的字段。
+// 查询、请求头与路径参数先于请求体绑定，form 标签的 default= 默认值不会覆盖请求体中的值。
+func bind(c *gin.Context, ptr any, tags bindTags) error {
+	query := c.Request.URL.Query()
+	if err := binding.MapFormWithTag(ptr, declared(tags.form, func(k string) []string { return query[k] }), "form"); err != nil {
+		return &bindError{source: "query", err: err}
+	}
+	if err := binding.MapFormWithTag(ptr, declared(tags.header, c.Request.Header.Values), "header"); err != nil {
+		return &bindError{source: "header", err: err}
+	}
+	param := func(k string) []string {
+		if v, ok := c.Params.Get(k); ok {
+			return []string{v}
+		}
+		return nil
+	}
+	if err := binding.MapFormWithTag(ptr, declared(tags.uri, param), "uri"); err != nil {
+		return &bindError{source: "path", err: err}
+	}
+
+	if hasBody(Method(c.Request.Method)) {
+		if err := bindBody(c.Request, ptr); err != nil {
+			return err
+		}
+	}
+
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(ptr)
+}
+
+// bindBody 解码 JSON 请求体，空请求体视为无内容。
+func bindBody(r *http.Request, ptr any) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mt, _, _ := mime.ParseMediaType(ct)
+		if mt != binding.MIMEJSON && !strings.HasSuffix(mt, "+json") {
+			return errUnsupportedMediaType
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(ptr); err != nil && !errors.Is(err, io.EOF) {
+		return &bindError{source: "body", err: err}
+	}
+	return nil
+}
+
+// tagNames 返回结构体中声明了 tag 标签的参数名。
+func tagNames(t reflect.Type, tag string) []string {
+	var names []string
+	forEachField(t, func(f reflect.StructField) {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// declared 返回 names 中存在取值的参数。
+func declared(names []string, values func(string) []string) map[string][]string {
+	m := make(map[string][]string, len(names))
+	for _, name := range names {
+		if v := values(name); len(v) > 0 {
+			m[name] = v
+		}
+	}
+	return m
+}
+
+// bindError 请求参数格式错误（类型不匹配、JSON 语法错误等）。
+type bindError struct {
+	source string
+	err    error
+}
+
+func (e *bindError) Error() string { return e.source + ": " + e.err.Error() }
+func (e *bindError) Unwrap() error { return e.err }
+
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// ============================================================================
+// 错误映射
+// ============================================================================
+
+// 处理函数可返回（或包装）以下错误，由 [Handle] 转换为对应的 HTTP 响应。
+//
+// 包装时使用 err.Error() 作为响应消息，直接返回哨兵错误时使用默认消息：
+//
+//	return UserDTO{}, routes.ErrNotFound                             // 404 资源不存在
+//	return UserDTO{}, fmt.Errorf("%w: 用户 %d", routes.ErrNotFound, id) // 404 not found: 用户 42
+//
+// 此外，包装了 context.DeadlineExceeded 的错误返回 504（response.GatewayTimeout）。
+var (
+	ErrBadRequest         = errors.New("bad request")          // 400
+	ErrUnauthorized       = errors.New("unauthorized")         // 401
+	ErrForbidden          = errors.New("forbidden")            // 403
+	ErrNotFound           = errors.New("not found")            // 404
+	ErrConflict           = errors.New("conflict")             // 409
+	ErrGone               = errors.New("gone")                 // 410
+	ErrPreconditionFailed = errors.New("precondition failed")  // 412
+	ErrUnprocessable      = errors.New("unprocessable entity") // 422
+	ErrTooManyRequests    = errors.New("too many requests")    // 429
+	ErrNotImplemented     = errors.New("not implemented")      // 501
+	ErrServiceUnavailable = errors.New("service unavailable")  // 503
+)
+
+// Error 携带状态码与详情的错误，适合需要完全控制响应内容的场景。
+// Status 不是 4xx/5xx（如未设置）时按 500 处理。
+//
+//	return UserDTO{}, &routes.Error{Status: http.StatusConflict, Message: "用户名已存在", Details: gin.H{"field": "username"}}
+type Error struct {
+	Status  int    // HTTP 状态码（4xx/5xx）
+	Message string // 响应消息，为空时使用状态码的默认消息
+	Details any    // 错误详情，写入响应的 error 字段
+	Err     error  // 原始错误（不返回给客户端）
+}
+
+func (e *Error) Error() string {
+	msg := cmp.Or(e.Message, http.StatusText(e.Status))
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// errorMapping 错误到状态码的映射。
+type errorMapping struct {
+	target error
+	status int
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   = []errorMapping{
+		{ErrBadRequest, http.StatusBadRequest},
+		{ErrUnauthorized, http.StatusUnauthorized},
+		{ErrForbidden, http.StatusForbidden},
+		{ErrNotFound, http.StatusNotFound},
+		{ErrConflict, http.StatusConflict},
+		{ErrGone, http.StatusGone},
+		{ErrPreconditionFailed, http.StatusPreconditionFailed},
+		{ErrUnprocessable, http.StatusUnprocessableEntity},
+		{ErrTooManyRequests, http.StatusTooManyRequests},
+		{ErrNotImplemented, http.StatusNotImplemented},
+		{ErrServiceUnavailable, http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+	}
+)
+
+// MapError 注册领域错误到 HTTP 状态码的映射，匹配使用 errors.Is。
+//
+// 后注册的映射优先，可覆盖内置规则。status 必须是 4xx/5xx，否则 panic。通常在 init 或启动时调用：
+//
+//	routes.MapError(domain.ErrUserNotFound, http.StatusNotFound)
+//	routes.MapError(domain.ErrEmailTaken, http.StatusConflict)
+func MapError(target error, status int) {
+	if !isErrorStatus(status) {
+		panic(fmt.Sprintf("routes: MapError: invalid error status %d", status))
+	}
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+	mappings = append(mappings, errorMapping{target: target, status: status})
+}
+
+// statusOf 返回错误映射的状态码与命中的目标错误，未映射时返回 0。
+func statusOf(err error) (int, error) {
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+	for i := len(mappings) - 1; i >= 0; i-- {
+		if errors.Is(err, mappings[i].target) {
+			return mappings[i].status, mappings[i].target
+		}
+	}
+	return 0, nil
+}
+
+// FieldError 单个字段的校验错误。
+type FieldError struct {
+	Field string `json:"field"`           // 字段路径，如 CreateUserReq.Name
+	Rule  string `json:"rule"`            // 未通过的规则，如 required、min
+	Param string `json:"param,omitempty"` // 规则参数，如 min=2 中的 2
+}
+
+// writeError 将错误转换为统一错误响应。
+//
+// 未映射的错误一律返回 500，原始错误通过 c.Error 记录到日志而不返回给客户端。
+func writeError(c *gin.Context, err error) {
+	var (
+		ve        validator.ValidationErrors
+		be        *bindError
+		tooLarge  *http.MaxBytesError
+		httpError *Error
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		response.PayloadTooLarge(c)
+	case errors.Is(err, errUnsupportedMediaType):
+		response.UnsupportedMediaType(c)
+	case errors.As(err, &ve):
+		details := make([]FieldError, len(ve))
+		for i, fe := range ve {
+			details[i] = FieldError{Field: fe.Namespace(), Rule: fe.Tag(), Param: fe.Param()}
+		}
+		response.ValidationError(c, details)
+	case errors.As(err, &be):
+		response.BadRequest(c, response.MsgValidationFailed, be.Error())
+	case errors.As(err, &httpError) && !isErrorStatus(httpError.Status):
+		_ = c.Error(fmt.Errorf("routes: invalid error status %d: %w", httpError.Status, err))
+		response.InternalError(c)
+	case errors.As(err, &httpError):
+		msg := cmp.Or(httpError.Message, http.StatusText(httpError.Status))
+		if httpError.Status >= http.StatusInternalServerError {
+			_ = c.Error(err)
+		}
+		response.Failure(c, httpError.Status, msg, httpError.Details)
+	default:
+		status, target := statusOf(err)
+		if status == 0 {
+			_ = c.Error(err)
+			response.InternalError(c)
+			return
+		}
+		writeStatus(c, status, err, target)
+	}
+}
+
+// isErrorStatus 报告 status 是否为错误响应可用的 4xx/5xx 状态码。
+func isErrorStatus(status int) bool {
+	return status >= http.StatusBadRequest && status <= 599
+}
+
+// writeStatus 按状态码调用对应的响应函数。
+//
+// err 与哨兵错误相同时使用默认消息，否则使用 err.Error()。
+func writeStatus(c *gin.Context, status int, err, target error) {
+	msg := ""
+	if err != target {
+		msg = err.Error()
+	}
+	if status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+
+	switch status {
+	case http.StatusBadRequest:
+		response.BadRequest(c, cmp.Or(msg, response.MsgValidationFailed))
+	case http.StatusUnauthorized:
+		response.Unauthorized(c, msg)
+	case http.StatusForbidden:
+		response.Forbidden(c, msg)
+	case http.StatusNotFound:
+		response.NotFoundMessage(c, msg)
+	case http.StatusConflict:
+		response.Conflict(c, msg)
+	case http.StatusGone:
+		response.Gone(c, msg)
+	case http.StatusPreconditionFailed:
+		response.PreconditionFailed(c, msg)
+	case http.StatusUnprocessableEntity:
+		response.UnprocessableEntity(c, nil, msg)
+	case http.StatusTooManyRequests:
+		response.TooManyRequests(c)
+	case http.StatusNotImplemented:
+		response.NotImplemented(c, msg)
+	case http.StatusServiceUnavailable:
+		response.ServiceUnavailable(c, msg)
+	case http.StatusGatewayTimeout:
+		response.GatewayTimeout(c) // 超时错误的原文可能包含内部细节
+	case http.StatusInternalServerError:
+		response.InternalError(c)
+	default:
+		response.Failure(c, status, cmp.Or(msg, http.StatusText(status)))
+	}
+}
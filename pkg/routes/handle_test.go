@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleBindOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type listReq struct {
+		ID    string `uri:"id"`
+		Page  int    `json:"page" form:"page,default=1"`
+		Sort  string `json:"sort" form:"sort,default=asc"`
+		Trace string `header:"X-Trace"`
+	}
+
+	r := gin.New()
+	r.POST("/items/:id", Handle(func(_ context.Context, req listReq) (listReq, error) {
+		return req, nil
+	}))
+
+	tests := []struct {
+		name  string
+		query string
+		body  string
+		want  string
+	}{
+		{"defaults", "", "", `"ID":"7","page":1,"sort":"asc","Trace":"t"`},
+		{"query", "?page=2&sort=desc", "", `"ID":"7","page":2,"sort":"desc","Trace":"t"`},
+		{"body overrides default", "", `{"page":3}`, `"ID":"7","page":3,"sort":"asc","Trace":"t"`},
+		{"body overrides query", "?page=2", `{"page":3,"sort":"desc"}`, `"ID":"7","page":3,"sort":"desc","Trace":"t"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/items/7"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Trace", "t")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.want) {
+				t.Fatalf("status = %d, body = %s; want %s", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleErrorStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"custom status", &Error{Status: http.StatusConflict, Message: "taken"}, http.StatusConflict},
+		{"missing status", &Error{Message: "oops"}, http.StatusInternalServerError},
+		{"non-error status", &Error{Status: http.StatusOK}, http.StatusInternalServerError},
+		{"out of range status", &Error{Status: 1000}, http.StatusInternalServerError},
+		{"sentinel", ErrNotFound, http.StatusNotFound},
+		{"unmapped", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", Handle(func(context.Context, struct{}) (struct{}, error) {
+				return struct{}{}, tt.err
+			}))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestMapErrorInvalidStatus(t *testing.T) {
+	for _, status := range []int{0, http.StatusOK, http.StatusFound, 600} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MapError(_, %d) did not panic", status)
+				}
+			}()
+			MapError(errors.New("x"), status)
+		}()
+	}
+}
@@ -0,0 +1,160 @@
+package routes
+
+import (
+	"cmp"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/permission"
+)
+
+// RouteInfo 已注册路由的描述信息。
+type RouteInfo struct {
+	Method      Method   `json:"method"`
+	Path        string   `json:"path"` // 含路由组前缀的完整路径
+	Operation   string   `json:"operation,omitempty"`
+	Version     int      `json:"version,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Middlewares []string `json:"middlewares,omitempty"` // 全局中间件 + 路由中间件，按执行顺序
+	Public      bool     `json:"public"`                // Operation 的 scope 为 public
+}
+
+// RouteFilter 路由筛选条件，空字段表示不限。
+type RouteFilter struct {
+	Tag       string // 标签（精确匹配）
+	Operation string // 操作模式，支持通配符，如 sys:users:*
+}
+
+// Inventory 路由清单，记录服务实际暴露的路由。
+//
+// 通过 [WithInventory] 在注册时收集，是并发安全的。
+type Inventory struct {
+	mu     sync.RWMutex
+	routes []RouteInfo
+}
+
+// NewInventory 创建空的路由清单。
+func NewInventory() *Inventory {
+	return &Inventory{}
+}
+
+// WithInventory 在注册路由时同时记录到清单。
+//
+// group 实现 BasePath() 时（如 *gin.RouterGroup）使用其基础路径作为前缀。
+func WithInventory(inv *Inventory) Option {
+	return func(o *options) { o.inventory = inv }
+}
+
+// Add 记录路由，prefix 为路由组的基础路径，mw 为注册时附加的全局中间件。
+func (inv *Inventory) Add(prefix string, rs []Route, mw ...gin.HandlerFunc) {
+	global := handlerNames(mw)
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	for _, r := range rs {
+		info := RouteInfo{
+			Method:      r.Method,
+			Path:        joinPath(prefix, r.Path),
+			Operation:   r.Operation,
+			Version:     r.Version,
+			Deprecated:  r.Deprecated,
+			Summary:     r.Summary,
+			Middlewares: append(slices.Clone(global), handlerNames(r.Middlewares)...),
+			Public:      permission.Operation(r.Operation).IsPublic(),
+		}
+		for tag := range strings.SplitSeq(r.Tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				info.Tags = append(info.Tags, tag)
+			}
+		}
+		inv.routes = append(inv.routes, info)
+	}
+}
+
+// Len 返回路由数量。
+func (inv *Inventory) Len() int {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return len(inv.routes)
+}
+
+// List 返回满足筛选条件的路由（按路径、方法排序，输出稳定便于比对）。
+func (inv *Inventory) List(f RouteFilter) []RouteInfo {
+	inv.mu.RLock()
+	out := make([]RouteInfo, 0, len(inv.routes))
+	for _, r := range inv.routes {
+		if f.match(r) {
+			out = append(out, r)
+		}
+	}
+	inv.mu.RUnlock()
+
+	slices.SortFunc(out, func(a, b RouteInfo) int {
+		return cmp.Or(
+			strings.Compare(a.Path, b.Path),
+			strings.Compare(string(a.Method), string(b.Method)),
+			cmp.Compare(a.Version, b.Version),
+		)
+	})
+	return out
+}
+
+// match 报告路由是否满足筛选条件。
+func (f RouteFilter) match(r RouteInfo) bool {
+	if f.Tag != "" && !slices.Contains(r.Tags, f.Tag) {
+		return false
+	}
+	if f.Operation != "" && !permission.MatchOperation(f.Operation, r.Operation) {
+		return false
+	}
+	return true
+}
+
+// handlerNames 返回处理函数的短名称，如 middleware.RequirePermissionWithConfig。
+func handlerNames(hs []gin.HandlerFunc) []string {
+	names := make([]string, 0, len(hs))
+	for _, h := range hs {
+		names = append(names, handlerName(h))
+	}
+	return names
+}
+
+// handlerName 去掉包路径与闭包后缀（.func1、.func1.2）。
+func handlerName(h gin.HandlerFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(h).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for {
+		i := strings.LastIndex(name, ".")
+		if i < 0 || !isClosureSuffix(name[i+1:]) {
+			return name
+		}
+		name = name[:i]
+	}
+}
+
+// isClosureSuffix 报告名称片段是否为编译器生成的闭包后缀（funcN 或纯数字）。
+func isClosureSuffix(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
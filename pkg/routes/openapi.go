@@ -0,0 +1,530 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/permission"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// OpenAPIVersion 生成文档使用的 OpenAPI 版本。
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIInfo 文档基本信息。
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPI OpenAPI 3.1 文档。
+//
+// 由声明式路由生成，无需手写 swag 注释：
+//   - operationId 取自 Route.Operation
+//   - 参数与请求体由 Route.Request 的 uri/form/header/json 标签反射生成
+//   - 成功响应将 Route.Response 包装为统一响应格式 {code, message, data}
+//   - 自动附带 400/401/403/404/500 统一错误响应
+//   - 以请求头或媒体类型区分版本（[WithVersioning]）时，同一方法 + 路径的各版本合并为一个 Operation：
+//     请求头策略增加版本请求头参数，各版本的响应以 oneOf 列出；
+//     媒体类型策略按版本媒体类型（如 application/vnd.acme.v2+json）列出各版本的响应
+//
+// 使用示例：
+//
+//	doc := routes.NewOpenAPI(routes.OpenAPIInfo{Title: "Admin API", Version: "1.0.0"})
+//	routes.MustRegister(api, userRoutes, routes.WithOpenAPI(doc))
+//	r.GET("/openapi.json", doc.Handler())
+type OpenAPI struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	mu      sync.Mutex
+	schemas *schemaBuilder
+}
+
+// PathItem 同一路径下各 HTTP 方法的操作，键为小写方法名。
+type PathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation OpenAPI Operation Object。
+type OpenAPIOperation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter OpenAPI Parameter Object。
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path、query、header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody OpenAPI Request Body Object。
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response OpenAPI Response Object（或对 components/responses 的引用）。
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType OpenAPI Media Type Object。
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components 可复用组件。
+type Components struct {
+	Schemas   map[string]*Schema   `json:"schemas"`
+	Responses map[string]*Response `json:"responses"`
+}
+
+// errorResponses 自动附带的统一错误响应：状态码 → 组件名、描述。
+var errorResponses = []struct {
+	status int
+	name   string
+	desc   string
+}{
+	{http.StatusBadRequest, "BadRequest", response.MsgValidationFailed},
+	{http.StatusUnauthorized, "Unauthorized", response.MsgAuthenticationRequired},
+	{http.StatusForbidden, "Forbidden", response.MsgAccessForbidden},
+	{http.StatusNotFound, "NotFound", response.MsgResourceNotFound},
+	{http.StatusTooManyRequests, "TooManyRequests", response.MsgRateLimitExceeded},
+	{http.StatusInternalServerError, "InternalError", response.MsgInternalError},
+}
+
+// NewOpenAPI 创建空文档，并注册统一错误响应组件。
+func NewOpenAPI(info OpenAPIInfo) *OpenAPI {
+	d := &OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas:   make(map[string]*Schema),
+			Responses: make(map[string]*Response),
+		},
+	}
+	d.schemas = newSchemaBuilder(d.Components.Schemas)
+
+	envelope := d.schemas.schemaOf(reflect.TypeFor[response.UnifiedResponse]())
+	for _, e := range errorResponses {
+		d.Components.Responses[e.name] = &Response{
+			Description: e.desc,
+			Content:     jsonContent(envelope),
+		}
+	}
+	return d
+}
+
+// AddRoutes 将路由加入文档，prefix 为路由组的基础路径（如 /api/v1）。
+func (d *OpenAPI) AddRoutes(prefix string, rs []Route) *OpenAPI {
+	d.addRoutes(prefix, rs, nil)
+	return d
+}
+
+// addRoutes 将路由加入文档，v 为 Register 使用的版本控制配置（可为 nil）。
+func (d *OpenAPI) addRoutes(prefix string, rs []Route, v *Versioning) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if v == nil || v.Strategy == VersionURL {
+		for _, r := range rs {
+			d.addRoute(prefix, r)
+		}
+		return
+	}
+
+	versions := versionsOf(rs)
+	def := v.Default
+	if def == 0 && len(versions) > 0 {
+		def = versions[len(versions)-1]
+	}
+	for _, ep := range groupEndpoints(rs) {
+		if ep.versioned() {
+			d.addVersioned(prefix, ep, v, def)
+		} else {
+			d.addRoute(prefix, ep.variants[0])
+		}
+	}
+}
+
+// Handler 返回输出文档 JSON 的处理函数。
+func (d *OpenAPI) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+// WithOpenAPI 在注册路由时同时将其加入文档。
+//
+// group 实现 BasePath() 时（如 *gin.RouterGroup）使用其基础路径作为前缀。
+func WithOpenAPI(d *OpenAPI) Option {
+	return func(o *options) { o.openapi = d }
+}
+
+// addRoute 生成单个路由的 Operation Object。调用方需持有 d.mu。
+func (d *OpenAPI) addRoute(prefix string, r Route) {
+	path, op := d.operation(prefix, r)
+	d.setOperation(path, r.Method, op)
+}
+
+// setOperation 将 Operation 写入路径。
+func (d *OpenAPI) setOperation(path string, m Method, op *OpenAPIOperation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[strings.ToLower(string(m))] = op
+}
+
+// operation 生成单个路由的 Operation Object，返回 OpenAPI 路径。
+func (d *OpenAPI) operation(prefix string, r Route) (string, *OpenAPIOperation) {
+	path, pathParams := openAPIPath(joinPath(prefix, r.Path))
+	op := &OpenAPIOperation{
+		OperationID: r.Operation,
+		Summary:     r.Summary,
+		Description: r.Description,
+		Deprecated:  r.Deprecated,
+		Responses:   make(map[string]*Response),
+	}
+	if op.OperationID == "" {
+		op.OperationID = strings.ToLower(string(r.Method)) + strings.NewReplacer("/", "_", "{", "", "}", "").Replace(path)
+	}
+	for tag := range strings.SplitSeq(r.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			op.Tags = append(op.Tags, tag)
+		}
+	}
+
+	d.addParameters(op, r, pathParams)
+
+	op.Responses[strconv.Itoa(http.StatusOK)] = &Response{
+		Description: response.MsgSuccess,
+		Content:     jsonContent(d.successSchema(r.Response)),
+	}
+	public := permission.Operation(r.Operation).IsPublic()
+	for _, e := range errorResponses {
+		switch {
+		case public && (e.status == http.StatusUnauthorized || e.status == http.StatusForbidden):
+			continue
+		case e.status == http.StatusNotFound && len(pathParams) == 0:
+			continue
+		case e.status == http.StatusTooManyRequests && r.RateLimit == nil:
+			continue
+		}
+		op.Responses[strconv.Itoa(e.status)] = &Response{Ref: "#/components/responses/" + e.name}
+	}
+	return path, op
+}
+
+// addVersioned 将同一方法 + 路径的各版本合并为一个 Operation，def 为请求未指定版本时使用的版本。
+//
+// 基本信息取自最新版本，参数与错误响应取各版本的并集；
+// 各版本的摘要与废弃状态列在描述中，全部版本都已废弃时 Operation 标记为废弃。
+func (d *OpenAPI) addVersioned(prefix string, ep *endpoint, cfg *Versioning, def int) {
+	var path string
+	ops := make([]*OpenAPIOperation, len(ep.variants))
+	for i, r := range ep.variants {
+		path, ops[i] = d.operation(prefix, r)
+	}
+
+	latest := ops[len(ops)-1]
+	op := &OpenAPIOperation{
+		OperationID: latest.OperationID,
+		Tags:        latest.Tags,
+		Summary:     latest.Summary,
+		Deprecated:  true,
+		Responses:   make(map[string]*Response),
+	}
+
+	var (
+		notes    []string
+		params   = make(map[string]bool)
+		bodies   []*Schema
+		versions []string
+	)
+	for i := len(ops) - 1; i >= 0; i-- {
+		r, vop := ep.variants[i], ops[i]
+		op.Deprecated = op.Deprecated && vop.Deprecated
+		for _, p := range vop.Parameters {
+			if key := p.In + ":" + p.Name; !params[key] {
+				params[key] = true
+				op.Parameters = append(op.Parameters, p)
+			}
+		}
+		for code, resp := range vop.Responses {
+			if _, ok := op.Responses[code]; !ok {
+				op.Responses[code] = resp
+			}
+		}
+		if vop.RequestBody != nil {
+			bodies = append(bodies, vop.RequestBody.Content["application/json"].Schema)
+		}
+
+		label := "默认"
+		if r.Version > 0 {
+			label = "v" + strconv.Itoa(r.Version)
+			versions = append(versions, strconv.Itoa(r.Version))
+		}
+		note := "- " + label
+		if vop.Summary != "" {
+			note += "：" + vop.Summary
+		}
+		if vop.Deprecated {
+			note += "（已废弃）"
+		}
+		notes = append(notes, note)
+	}
+	slices.Reverse(notes)
+	slices.Reverse(versions)
+	op.Description = strings.TrimSpace(latest.Description + "\n\n版本：\n" + strings.Join(notes, "\n"))
+
+	if len(bodies) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: jsonContent(oneOf(bodies))}
+	}
+
+	ok := strconv.Itoa(http.StatusOK)
+	success := &Response{Description: response.MsgSuccess, Content: make(map[string]*MediaType)}
+	op.Responses[ok] = success
+	if cfg.Strategy == VersionHeader {
+		schemas := make([]*Schema, len(ops))
+		for i, vop := range ops {
+			schemas[i] = vop.Responses[ok].Content["application/json"].Schema
+		}
+		success.Content = jsonContent(oneOf(schemas))
+		minVersion := 1.0
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        cfg.Header,
+			In:          "header",
+			Description: "API 版本（" + strings.Join(versions, "、") + "），缺省为 " + strconv.Itoa(def) + "，其他版本回退到最近的较低版本",
+			Schema:      &Schema{Type: "integer", Minimum: &minVersion},
+		})
+	} else {
+		for i, r := range ep.variants {
+			schema := ops[i].Responses[ok].Content["application/json"].Schema
+			if r.Version > 0 {
+				success.Content[versionMediaType(cfg, r.Version)] = &MediaType{Schema: schema}
+			}
+			if j := ep.pick(def); j == i {
+				success.Content["application/json"] = &MediaType{Schema: schema}
+			}
+		}
+	}
+
+	d.setOperation(path, ep.method, op)
+}
+
+// versionMediaType 返回指定版本的媒体类型，如 application/vnd.acme.v2+json 或 application/json; version=2。
+func versionMediaType(cfg *Versioning, v int) string {
+	if cfg.Vendor != "" {
+		return "application/vnd." + cfg.Vendor + ".v" + strconv.Itoa(v) + "+json"
+	}
+	return "application/json; version=" + strconv.Itoa(v)
+}
+
+// oneOf 合并各版本的 schema，相同的 schema 只保留一个，全部相同时直接返回该 schema。
+func oneOf(schemas []*Schema) *Schema {
+	var (
+		distinct []*Schema
+		seen     = make(map[string]bool)
+	)
+	for _, s := range schemas {
+		b, _ := json.Marshal(s)
+		if !seen[string(b)] {
+			seen[string(b)] = true
+			distinct = append(distinct, s)
+		}
+	}
+	if len(distinct) == 1 {
+		return distinct[0]
+	}
+	return &Schema{OneOf: distinct}
+}
+
+// addParameters 从 Route.Request 生成路径/查询/头参数与请求体。
+func (d *OpenAPI) addParameters(op *OpenAPIOperation, r Route, pathParams []string) {
+	declared := make(map[string]bool)
+
+	var t reflect.Type
+	if r.Request != nil {
+		t = reflect.TypeOf(r.Request)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+
+	if t != nil && t.Kind() == reflect.Struct {
+		body := hasBody(r.Method)
+		forEachField(t, func(f reflect.StructField) {
+			for _, src := range []struct{ tag, in string }{{"uri", "path"}, {"header", "header"}, {"form", "query"}} {
+				name, _, _ := strings.Cut(f.Tag.Get(src.tag), ",")
+				if name == "" || name == "-" {
+					continue
+				}
+				p := &Parameter{
+					Name:     name,
+					In:       src.in,
+					Required: src.in == "path" || isRequired(f),
+					Schema:   d.schemas.schemaOf(f.Type),
+				}
+				applyTags(p.Schema, f)
+				op.Parameters = append(op.Parameters, p)
+				if src.in == "path" {
+					declared[name] = true
+				}
+			}
+		})
+
+		if body {
+			// 只有 form 标签的字段是查询参数，不出现在请求体中
+			schema := d.schemas.structSchema(t, func(f reflect.StructField) bool {
+				return f.Tag.Get("uri") != "" || f.Tag.Get("header") != "" ||
+					(f.Tag.Get("form") != "" && f.Tag.Get("json") == "")
+			})
+			if len(schema.Properties) > 0 {
+				op.RequestBody = &RequestBody{Required: true, Content: jsonContent(schema)}
+			}
+		}
+	}
+
+	// 未在 Request 中声明的路径参数按字符串处理
+	for _, name := range pathParams {
+		if !declared[name] {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+	}
+}
+
+// successSchema 返回成功响应的 schema。
+//
+// data 为 nil 时使用 MessageResponse；已是统一响应结构（含 Code、Message 字段）时原样使用；
+// 否则包装为 {code, message, data}。
+func (d *OpenAPI) successSchema(data any) *Schema {
+	if data == nil {
+		return d.schemas.schemaOf(reflect.TypeFor[response.MessageResponse]())
+	}
+	t := reflect.TypeOf(data)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if isEnvelope(t) {
+		return d.schemas.schemaOf(t)
+	}
+
+	envelope := func() *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"code":    {Type: "integer", Format: "int32", Description: "HTTP 状态码"},
+				"message": {Type: "string", Description: "消息描述"},
+				"data":    d.schemas.schemaOf(t),
+			},
+			Required: []string{"code", "message"},
+		}
+	}
+
+	// 匿名类型（切片、map 等）没有合法的组件名，直接内联
+	if t.Name() == "" {
+		return envelope()
+	}
+	return d.schemas.envelopeRef(t, envelope)
+}
+
+// isEnvelope 报告类型是否已是统一响应结构。
+func isEnvelope(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, hasCode := t.FieldByName("Code")
+	_, hasMessage := t.FieldByName("Message")
+	return hasCode && hasMessage
+}
+
+// forEachField 遍历结构体字段（展开匿名嵌入结构体）。
+func forEachField(t reflect.Type, fn func(f reflect.StructField)) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			forEachField(ft, fn)
+			continue
+		}
+		if f.IsExported() {
+			fn(f)
+		}
+	}
+}
+
+// hasBody 报告方法是否携带请求体。
+func hasBody(m Method) bool {
+	return m == POST || m == PUT || m == PATCH
+}
+
+// jsonContent 构造 application/json 内容。
+func jsonContent(s *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: s}}
+}
+
+// openAPIPath 将 Gin 路径转换为 OpenAPI 路径，返回路径参数名。
+//
+//	/users/:id/files/*path → /users/{id}/files/{path}
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, seg[1:])
+			segs[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/"), params
+}
+
+// joinPath 拼接基础路径与相对路径。
+func joinPath(prefix, path string) string {
+	if prefix == "" || prefix == "/" {
+		if path == "" {
+			return "/"
+		}
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// basePath 返回路由组的基础路径，不支持时返回空。
+func basePath(group gin.IRouter) string {
+	if g, ok := group.(interface{ BasePath() string }); ok {
+		return g.BasePath()
+	}
+	return ""
+}
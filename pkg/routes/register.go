@@ -0,0 +1,236 @@
+package routes
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/middleware"
+)
+
+// 路由注册错误。
+var (
+	// ErrInvalidRoute 路由定义不完整（缺少方法、处理函数等）。
+	ErrInvalidRoute = errors.New("routes: invalid route")
+	// ErrDuplicateRoute 方法与路径重复（路径参数名不同也视为重复）。
+	ErrDuplicateRoute = errors.New("routes: duplicate route")
+	// ErrDuplicateOperation Operation 被多个路由使用。
+	ErrDuplicateOperation = errors.New("routes: duplicate operation")
+)
+
+// Option 配置 [Register]。
+type Option func(*options)
+
+type options struct {
+	middlewares []gin.HandlerFunc
+	hooks       []func(r Route) error
+	openapi     *OpenAPI
+	inventory   *Inventory
+	versioning  *Versioning
+	limiter     *middleware.RateLimiter
+}
+
+// WithMiddlewares 为所有路由添加中间件。
+//
+// 执行顺序见 [Register]：全局中间件位于路由级超时、请求体限制、废弃响应头与请求体日志之后，
+// 路由限流与路由自身的 Middlewares 之前。
+// 适合放置依赖 Operation ID 的中间件，如 middleware.RequirePermission()。
+func WithMiddlewares(mw ...gin.HandlerFunc) Option {
+	return func(o *options) { o.middlewares = append(o.middlewares, mw...) }
+}
+
+// WithHook 添加注册钩子，在安装每个路由之前调用。
+//
+// 钩子可用于额外校验或收集路由信息，返回错误会中止注册。
+func WithHook(fn func(r Route) error) Option {
+	return func(o *options) { o.hooks = append(o.hooks, fn) }
+}
+
+// WithRateLimiter 指定 Route.RateLimit 使用的限流器（存储与计数键）。
+//
+// 未指定时使用内存存储、按客户端 IP 计数的默认限流器。
+// 规则未设置 Name 时以 Operation（为空时为方法 + 路径）命名，各路由的配额互不影响。
+func WithRateLimiter(l *middleware.RateLimiter) Option {
+	return func(o *options) { o.limiter = l }
+}
+
+// Register 将声明式路由安装到 group。
+//
+// 每个路由的处理链为：
+//
+//	middleware.SetOperationID(route.Operation) → middleware.RouteTimeout(route.Timeout)
+//	→ middleware.RouteBodyLimit(route.BodyLimit) → 废弃响应头（Deprecated、Sunset）→ middleware.LogBody()
+//	→ 全局中间件 → 路由限流 → route.Middlewares → route.Handler
+//
+// 其中路由级中间件只在对应字段设置时添加。
+//
+// 安装前会校验整张路由表，校验失败时不会安装任何路由：
+//   - Method、Handler 不能为空，Version 不能为负，RateLimit 的 Requests、Window 必须为正
+//   - 方法 + 路径不能重复（[ErrDuplicateRoute]），启用 [WithVersioning] 时按版本区分
+//   - 非空 Operation 不能重复（[ErrDuplicateOperation]），同一路径的不同版本除外
+//
+// 路径语法错误或与已有路由冲突（如 /users/:id 与 /users/:name）由 Gin 在安装时检测，
+// 此时返回 [ErrInvalidRoute]，但出错路由之前的路由已经安装，应将其视为启动失败。
+//
+// 示例：
+//
+//	err := routes.Register(r.Group("/api"), []routes.Route{
+//	    {Method: routes.GET, Path: "/users", Operation: "sys:users:list", Handler: listUsers},
+//	    {Method: routes.POST, Path: "/users", Operation: "sys:users:create", Handler: createUser},
+//	}, routes.WithMiddlewares(middleware.RequirePermission()))
+func Register(group gin.IRouter, rs []Route, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := validate(rs, o.versioning != nil); err != nil {
+		return err
+	}
+	if o.limiter == nil && slices.ContainsFunc(rs, func(r Route) bool { return r.RateLimit != nil }) {
+		o.limiter = middleware.NewRateLimiter(middleware.RateLimitConfig{})
+	}
+	for _, r := range rs {
+		for _, hook := range o.hooks {
+			if err := hook(r); err != nil {
+				return fmt.Errorf("routes: %s %s: %w", r.Method, r.Path, err)
+			}
+		}
+	}
+
+	docs := rs
+	if o.versioning != nil {
+		var err error
+		if docs, err = installVersioned(group, rs, &o); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range rs {
+			if err := install(group, r.Method, r.Path, chain(r, &o)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.openapi != nil {
+		o.openapi.addRoutes(basePath(group), docs, o.versioning)
+	}
+	if o.inventory != nil {
+		o.inventory.Add(basePath(group), docs, o.middlewares...)
+	}
+	return nil
+}
+
+// MustRegister 同 [Register]，失败时 panic。
+func MustRegister(group gin.IRouter, rs []Route, opts ...Option) {
+	if err := Register(group, rs, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// chain 构造路由的处理链。
+func chain(r Route, o *options) []gin.HandlerFunc {
+	handlers := make([]gin.HandlerFunc, 0, len(o.middlewares)+len(r.Middlewares)+7)
+	if r.Operation != "" {
+		handlers = append(handlers, middleware.SetOperationID(r.Operation))
+	}
+	if r.Timeout != 0 {
+		handlers = append(handlers, middleware.RouteTimeout(r.Timeout))
+	}
+	if r.BodyLimit != 0 {
+		handlers = append(handlers, middleware.RouteBodyLimit(r.BodyLimit))
+	}
+	if h := deprecation(r); h != nil {
+		handlers = append(handlers, h)
+	}
+	if r.LogBody {
+		handlers = append(handlers, middleware.LogBody())
+	}
+	handlers = append(handlers, o.middlewares...)
+	if r.RateLimit != nil {
+		// 位于全局中间件之后，使认证设置的 user_id 可用于计数键
+		limit := *r.RateLimit
+		if limit.Name == "" {
+			limit.Name = cmp.Or(r.Operation, string(r.Method)+" "+r.Path)
+		}
+		handlers = append(handlers, o.limiter.Limit(limit))
+	}
+	handlers = append(handlers, r.Middlewares...)
+	return append(handlers, r.Handler)
+}
+
+// install 安装处理链，将 gin 的冲突 panic 转换为错误。
+func install(group gin.IRouter, method Method, path string, handlers []gin.HandlerFunc) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%w: %s %s: %v", ErrInvalidRoute, method, path, rec)
+		}
+	}()
+
+	group.Handle(string(method), path, handlers...)
+	return nil
+}
+
+// validate 校验整张路由表，versioned 为 true 时同一路径的不同版本不视为重复。
+func validate(rs []Route, versioned bool) error {
+	var errs []error
+	paths := make(map[string]int, len(rs))
+	ops := make(map[string]int, len(rs))
+
+	for i, r := range rs {
+		switch {
+		case r.Method == "":
+			errs = append(errs, fmt.Errorf("%w: #%d %s: empty method", ErrInvalidRoute, i, r.Path))
+			continue
+		case r.Handler == nil:
+			errs = append(errs, fmt.Errorf("%w: #%d %s %s: nil handler", ErrInvalidRoute, i, r.Method, r.Path))
+			continue
+		case r.Version < 0:
+			errs = append(errs, fmt.Errorf("%w: #%d %s %s: negative version", ErrInvalidRoute, i, r.Method, r.Path))
+			continue
+		case r.RateLimit != nil && (r.RateLimit.Requests <= 0 || r.RateLimit.Window <= 0):
+			errs = append(errs, fmt.Errorf("%w: #%d %s %s: invalid rate limit", ErrInvalidRoute, i, r.Method, r.Path))
+			continue
+		}
+
+		endpoint := string(r.Method) + " " + normalizePath(r.Path)
+		key := endpoint
+		if versioned {
+			key += " v" + strconv.Itoa(r.Version)
+		}
+		if j, dup := paths[key]; dup {
+			errs = append(errs, fmt.Errorf("%w: #%d %s %s conflicts with #%d %s %s",
+				ErrDuplicateRoute, i, r.Method, r.Path, j, rs[j].Method, rs[j].Path))
+		} else {
+			paths[key] = i
+		}
+
+		if r.Operation == "" {
+			continue
+		}
+		// 同一路径的不同版本可以共用 Operation
+		if j, dup := ops[r.Operation]; dup && string(rs[j].Method)+" "+normalizePath(rs[j].Path) != endpoint {
+			errs = append(errs, fmt.Errorf("%w: %q used by #%d %s %s and #%d %s %s",
+				ErrDuplicateOperation, r.Operation, j, rs[j].Method, rs[j].Path, i, r.Method, r.Path))
+		} else {
+			ops[r.Operation] = i
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// normalizePath 去掉路径参数名，使 /users/:id 与 /users/:uid 视为同一路径。
+func normalizePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+			segs[i] = seg[:1]
+		}
+	}
+	return strings.Join(segs, "/")
+}
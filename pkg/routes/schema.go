@@ -0,0 +1,267 @@
+package routes
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema OpenAPI 3.1 Schema Object（JSON Schema 子集）。
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Example              any                `json:"example,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// schemaBuilder 通过反射生成 schema，具名结构体注册为可复用组件。
+type schemaBuilder struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	envelopes  map[reflect.Type]string // 统一响应包装组件名
+}
+
+func newSchemaBuilder(components map[string]*Schema) *schemaBuilder {
+	return &schemaBuilder{
+		components: components,
+		names:      make(map[reflect.Type]string),
+		envelopes:  make(map[reflect.Type]string),
+	}
+}
+
+var (
+	timeType           = reflect.TypeFor[time.Time]()
+	jsonRawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaOf 返回类型的 schema，具名结构体返回 $ref。
+func (b *schemaBuilder) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case jsonRawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t, nil)
+		}
+		return b.ref(t)
+	default: // interface、func 等：任意值
+		return &Schema{}
+	}
+}
+
+// ref 注册具名结构体组件并返回引用。
+func (b *schemaBuilder) ref(t reflect.Type) *Schema {
+	name, ok := b.names[t]
+	if !ok {
+		name = b.uniqueName(schemaName(t))
+		b.names[t] = name
+		b.components[name] = &Schema{} // 占位，防止递归类型无限展开
+		*b.components[name] = *b.structSchema(t, nil)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// envelopeRef 返回包装类型 t 的统一响应组件引用，build 生成组件 schema。
+//
+// 组件名为 DataResponse_ 加类型名，不同包的同名类型按 [schemaBuilder.uniqueName] 追加序号。
+func (b *schemaBuilder) envelopeRef(t reflect.Type, build func() *Schema) *Schema {
+	name, ok := b.envelopes[t]
+	if !ok {
+		name = b.uniqueName("DataResponse_" + schemaName(t))
+		b.envelopes[t] = name
+		b.components[name] = build()
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// uniqueName 生成组件名，已被占用时追加序号。
+func (b *schemaBuilder) uniqueName(base string) string {
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := b.components[name]; !taken {
+			return name
+		}
+		name = base + strconv.Itoa(i)
+	}
+}
+
+// structSchema 生成结构体的 object schema。
+//
+// skip 返回 true 的字段会被忽略（用于从请求体中剔除路径/头参数）。
+func (b *schemaBuilder) structSchema(t reflect.Type, skip func(f reflect.StructField) bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(s, t, skip)
+	return s
+}
+
+func (b *schemaBuilder) addFields(s *Schema, t reflect.Type, skip func(f reflect.StructField) bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() || (skip != nil && skip(f)) {
+			continue
+		}
+
+		name, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+
+		// 匿名嵌入且无 json 名：展开字段（与 encoding/json 行为一致）
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(s, ft, skip)
+				continue
+			}
+		}
+
+		fs := b.schemaOf(f.Type)
+		applyTags(fs, f)
+		s.Properties[name] = fs
+		if isRequired(f) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// jsonName 返回字段的 JSON 名称；字段被 json:"-" 忽略时 ok 为 false。
+func jsonName(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// isRequired 根据 binding 标签判断字段是否必填。
+func isRequired(f reflect.StructField) bool {
+	for rule := range strings.SplitSeq(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTags 读取 swag 风格的文档标签（minimum、maximum、default、example、enums、format）。
+//
+// $ref 字段不附加任何信息，避免破坏引用。
+func applyTags(s *Schema, f reflect.StructField) {
+	if s.Ref != "" {
+		return
+	}
+	if v, ok := f.Tag.Lookup("minimum"); ok {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			s.Minimum = &n
+		}
+	}
+	if v, ok := f.Tag.Lookup("maximum"); ok {
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			s.Maximum = &n
+		}
+	}
+	if v, ok := f.Tag.Lookup("default"); ok {
+		s.Default = typedValue(s.Type, v)
+	}
+	if v, ok := f.Tag.Lookup("example"); ok {
+		s.Example = typedValue(s.Type, v)
+	}
+	if v, ok := f.Tag.Lookup("enums"); ok {
+		for e := range strings.SplitSeq(v, ",") {
+			s.Enum = append(s.Enum, typedValue(s.Type, strings.TrimSpace(e)))
+		}
+	}
+	if v, ok := f.Tag.Lookup("format"); ok {
+		s.Format = v
+	}
+	if v, ok := f.Tag.Lookup("description"); ok {
+		s.Description = v
+	}
+}
+
+// typedValue 按 schema 类型转换标签中的字符串值。
+func typedValue(typ, v string) any {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// schemaName 生成组件名。
+//
+// 泛型类型去掉包路径：DataResponse[github.com/x/dto.UserDTO] → DataResponse_UserDTO。
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	base, args, generic := strings.Cut(name, "[")
+	if !generic {
+		return name
+	}
+	args = strings.TrimSuffix(args, "]")
+	var parts []string
+	for arg := range strings.SplitSeq(args, ",") {
+		arg = strings.TrimLeft(arg, "*[]")
+		if i := strings.LastIndex(arg, "."); i >= 0 {
+			arg = arg[i+1:]
+		}
+		parts = append(parts, arg)
+	}
+	return base + "_" + strings.Join(parts, "_")
+}
@@ -1,6 +1,12 @@
 package routes
 
-import "github.com/gin-gonic/gin"
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/middleware"
+)
 
 // Method HTTP 方法类型
 type Method string
@@ -23,9 +29,20 @@ type Route struct {
 	Operation   string            // Operation: domain:resource:action
 	Handler     gin.HandlerFunc   // 处理函数
 	Middlewares []gin.HandlerFunc // 中间件列表
+	LogBody     bool              // 访问日志记录请求/响应体（需 LoggerConfig.Body.PerRoute）
+	RateLimit   *middleware.Limit // 路由级限流（见 WithRateLimiter），nil 表示不限流
+	Timeout     time.Duration     // 路由级超时（见 middleware.RouteTimeout），0 表示使用默认值，负值表示不限制
+	BodyLimit   int64             // 路由级请求体上限（见 middleware.RouteBodyLimit），0 表示使用默认值，负值表示不限制
+
+	// 版本控制（见 WithVersioning）
+	Version    int       // API 版本（1、2…），0 表示不区分版本
+	Deprecated bool      // 已废弃：响应附带 Deprecation 头
+	Sunset     time.Time // 计划下线时间：响应附带 Sunset 头
 
-	// Swagger 文档
-	Tags        string
+	// OpenAPI 文档
+	Tags        string // 标签，多个使用逗号分隔
 	Summary     string
 	Description string
+	Request     any // 请求类型的零值（如 CreateUserReq{}），用于生成参数与请求体 schema
+	Response    any // 响应数据类型的零值（如 UserDTO{}），自动包装为统一响应格式
 }
@@ -0,0 +1,317 @@
+package routes
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/ctxutil"
+	"github.com/lwmacct/260101-go-pkg-gin/pkg/response"
+)
+
+// VersionStrategy 客户端指定 API 版本的方式。
+type VersionStrategy int
+
+const (
+	// VersionURL 路径前缀：/api/v2/users
+	VersionURL VersionStrategy = iota
+	// VersionHeader 请求头：Accept-Version: 2
+	VersionHeader
+	// VersionMediaType 媒体类型：Accept: application/vnd.acme.v2+json 或 application/json; version=2
+	VersionMediaType
+)
+
+// DefaultVersionHeader 默认的版本请求头。
+const DefaultVersionHeader = "Accept-Version"
+
+// Versioning 版本控制配置。
+type Versioning struct {
+	Strategy VersionStrategy
+
+	// Header 版本请求头名称（VersionHeader），默认 Accept-Version
+	Header string
+
+	// Vendor 厂商媒体类型名称（VersionMediaType），如 "acme" 匹配 application/vnd.acme.v2+json；
+	// 为空时只识别 version 参数
+	Vendor string
+
+	// Default 请求未指定版本时使用的版本（VersionHeader、VersionMediaType），0 表示最新版本
+	Default int
+}
+
+// WithVersioning 启用版本控制，使一张路由表同时服务多个 API 版本。
+//
+// 同一方法 + 路径的多个路由以 Route.Version 区分，请求版本 v 由 Version ≤ v 中最高的路由处理
+// （回退到最近的较低版本）；Version 为 0 的路由对所有版本生效。
+//
+// 各策略的注册方式：
+//   - VersionURL：有版本化变体的路由注册在 /v{N} 前缀下（N 取表中出现的全部版本），
+//     Version 为 0 的路由注册在原路径
+//   - VersionHeader、VersionMediaType：每个路径只注册一次，按请求版本分派到对应路由的处理链；
+//     版本格式错误返回 400，请求版本低于该路径的最低版本返回 404
+//
+// 处理函数可通过 ctxutil.Get[int](c, ctxutil.APIVersion) 读取请求版本。
+//
+// 示例：
+//
+//	routes.MustRegister(api, []routes.Route{
+//	    {Method: routes.GET, Path: "/users", Version: 1, Handler: listUsersV1, Deprecated: true,
+//	        Sunset: time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)},
+//	    {Method: routes.GET, Path: "/users", Version: 2, Handler: listUsersV2},
+//	    {Method: routes.GET, Path: "/orders", Version: 1, Handler: listOrders}, // v2 回退到 v1
+//	}, routes.WithVersioning(routes.Versioning{Strategy: routes.VersionURL}))
+func WithVersioning(v Versioning) Option {
+	if v.Header == "" {
+		v.Header = DefaultVersionHeader
+	}
+	return func(o *options) { o.versioning = &v }
+}
+
+// endpoint 同一方法 + 路径的全部版本变体（按 Version 升序）。
+type endpoint struct {
+	method   Method
+	path     string
+	variants []Route
+}
+
+// groupEndpoints 按方法 + 路径分组，保持首次出现的顺序。
+func groupEndpoints(rs []Route) []*endpoint {
+	var eps []*endpoint
+	index := make(map[string]*endpoint)
+	for _, r := range rs {
+		key := string(r.Method) + " " + normalizePath(r.Path)
+		ep, ok := index[key]
+		if !ok {
+			ep = &endpoint{method: r.Method, path: r.Path}
+			index[key] = ep
+			eps = append(eps, ep)
+		}
+		ep.variants = append(ep.variants, r)
+	}
+	for _, ep := range eps {
+		slices.SortStableFunc(ep.variants, func(a, b Route) int { return cmp.Compare(a.Version, b.Version) })
+	}
+	return eps
+}
+
+// pick 返回处理版本 v 的变体索引（Version ≤ v 中最高者），不存在时返回 -1。
+func (ep *endpoint) pick(v int) int {
+	for i := len(ep.variants) - 1; i >= 0; i-- {
+		if ep.variants[i].Version <= v {
+			return i
+		}
+	}
+	return -1
+}
+
+// versioned 报告路径是否存在版本化变体。
+func (ep *endpoint) versioned() bool {
+	return ep.variants[len(ep.variants)-1].Version > 0
+}
+
+// versionsOf 返回路由表中出现的全部版本（升序，不含 0）。
+func versionsOf(rs []Route) []int {
+	var vs []int
+	for _, r := range rs {
+		if r.Version > 0 && !slices.Contains(vs, r.Version) {
+			vs = append(vs, r.Version)
+		}
+	}
+	slices.Sort(vs)
+	return vs
+}
+
+// installVersioned 按版本控制策略安装路由，返回用于文档与清单的路由（路径含版本前缀）。
+func installVersioned(group gin.IRouter, rs []Route, o *options) ([]Route, error) {
+	cfg := o.versioning
+	versions := versionsOf(rs)
+	latest := 0
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1]
+	}
+
+	docs := make([]Route, 0, len(rs))
+	for _, ep := range groupEndpoints(rs) {
+		if !ep.versioned() {
+			r := ep.variants[0]
+			if err := install(group, r.Method, r.Path, chain(r, o)); err != nil {
+				return nil, err
+			}
+			docs = append(docs, r)
+			continue
+		}
+
+		if cfg.Strategy == VersionURL {
+			if ep.variants[0].Version == 0 {
+				r := ep.variants[0]
+				if err := install(group, r.Method, r.Path, chain(r, o)); err != nil {
+					return nil, err
+				}
+				docs = append(docs, r)
+			}
+			for _, v := range versions {
+				i := ep.pick(v)
+				if i < 0 {
+					continue
+				}
+				r := ep.variants[i]
+				path := versionPrefix(v) + r.Path
+				handlers := append([]gin.HandlerFunc{setVersion(v)}, chain(r, o)...)
+				if err := install(group, r.Method, path, handlers); err != nil {
+					return nil, err
+				}
+				if r.Version == v {
+					r.Path = path
+					docs = append(docs, r)
+				}
+			}
+			continue
+		}
+
+		chains := make([][]gin.HandlerFunc, len(ep.variants))
+		for i, r := range ep.variants {
+			chains[i] = chain(r, o)
+		}
+		if err := install(group, ep.method, ep.path, dispatch(ep, chains, cfg, latest)); err != nil {
+			return nil, err
+		}
+		docs = append(docs, ep.variants...)
+	}
+	return docs, nil
+}
+
+// versionPrefix 返回版本路径前缀，如 /v2。
+func versionPrefix(v int) string {
+	return "/v" + strconv.Itoa(v)
+}
+
+// setVersion 记录请求版本。
+func setVersion(v int) gin.HandlerFunc {
+	return func(c *gin.Context) { c.Set(ctxutil.APIVersion, v) }
+}
+
+// versionChainKey 当前请求选中的处理链。
+const versionChainKey = "routes_version_chain"
+
+// dispatch 构造按请求版本分派的处理链。
+//
+// Gin 每个路径只能注册一条处理链，因此注册 [选择器, 槽位 0 … 槽位 N-1]，
+// N 为各变体处理链的最大长度：选择器选出变体后，槽位 i 执行该变体的第 i 个处理函数
+// （超出长度的槽位为空操作）。中间件中的 c.Next() 与 c.Abort() 语义保持不变。
+func dispatch(ep *endpoint, chains [][]gin.HandlerFunc, cfg *Versioning, latest int) []gin.HandlerFunc {
+	vary := cfg.Header
+	if cfg.Strategy == VersionMediaType {
+		vary = "Accept"
+	}
+
+	size := 0
+	for _, ch := range chains {
+		size = max(size, len(ch))
+	}
+
+	handlers := make([]gin.HandlerFunc, 0, size+1)
+	handlers = append(handlers, func(c *gin.Context) {
+		addVary(c.Writer.Header(), vary)
+		v, ok := requestedVersion(c, cfg)
+		if !ok {
+			response.BadRequest(c, "无效的 API 版本")
+			c.Abort()
+			return
+		}
+		if v == 0 {
+			v = cmp.Or(cfg.Default, latest)
+		}
+		i := ep.pick(v)
+		if i < 0 {
+			response.NotFound(c, "")
+			c.Abort()
+			return
+		}
+		c.Set(ctxutil.APIVersion, v)
+		c.Set(versionChainKey, chains[i])
+	})
+	for slot := range size {
+		handlers = append(handlers, func(c *gin.Context) {
+			ch, _ := ctxutil.Get[[]gin.HandlerFunc](c, versionChainKey)
+			if slot < len(ch) {
+				ch[slot](c)
+			}
+		})
+	}
+	return handlers
+}
+
+// addVary 将 name 追加到 Vary 响应头，保留已有取值（如 CORS 设置的 Origin），已存在时不重复添加。
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for field := range strings.SplitSeq(v, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
+
+// requestedVersion 解析请求指定的版本，未指定时返回 0。
+func requestedVersion(c *gin.Context, cfg *Versioning) (int, bool) {
+	if cfg.Strategy == VersionHeader {
+		s := strings.TrimSpace(c.GetHeader(cfg.Header))
+		if s == "" {
+			return 0, true
+		}
+		return parseVersion(s)
+	}
+
+	for mt := range strings.SplitSeq(c.GetHeader("Accept"), ",") {
+		typ, params, _ := strings.Cut(mt, ";")
+		typ = strings.TrimSpace(typ)
+
+		if cfg.Vendor != "" {
+			prefix := "application/vnd." + cfg.Vendor + "."
+			if rest, ok := strings.CutPrefix(typ, prefix); ok {
+				rest, _, _ = strings.Cut(rest, "+")
+				return parseVersion(rest)
+			}
+		}
+		for p := range strings.SplitSeq(params, ";") {
+			if k, val, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(k, "version") {
+				return parseVersion(strings.Trim(val, `"`))
+			}
+		}
+	}
+	return 0, true
+}
+
+// parseVersion 解析 "2" 或 "v2"。
+func parseVersion(s string) (int, bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+// deprecation 为已废弃的路由设置 Deprecation 与 Sunset 响应头。
+func deprecation(r Route) gin.HandlerFunc {
+	if !r.Deprecated && r.Sunset.IsZero() {
+		return nil
+	}
+	sunset := ""
+	if !r.Sunset.IsZero() {
+		sunset = r.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(c *gin.Context) {
+		if r.Deprecated {
+			c.Header("Deprecation", "true")
+		}
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDispatchKeepsVary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		existing []string
+		want     []string
+	}{
+		{"no existing value", nil, []string{"Accept-Version"}},
+		{"keeps CORS origin", []string{"Origin"}, []string{"Origin", "Accept-Version"}},
+		{"no duplicate", []string{"Origin, accept-version"}, []string{"Origin, accept-version"}},
+		{"wildcard", []string{"*"}, []string{"*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				for _, v := range tt.existing {
+					c.Writer.Header().Add("Vary", v)
+				}
+			})
+			MustRegister(r, []Route{
+				{Method: GET, Path: "/users", Version: 1, Handler: func(c *gin.Context) { c.Status(http.StatusOK) }},
+				{Method: GET, Path: "/users", Version: 2, Handler: func(c *gin.Context) { c.Status(http.StatusOK) }},
+			}, WithVersioning(Versioning{Strategy: VersionHeader}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set(DefaultVersionHeader, "1")
+			r.ServeHTTP(w, req)
+			if got := w.Header().Values("Vary"); !slices.Equal(got, tt.want) {
+				t.Fatalf("Vary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}